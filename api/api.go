@@ -22,23 +22,321 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"git-sync/git"
+	"git-sync/internal/interfaces"
+	"git-sync/internal/models"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-// StatusResponse Структура ответа API
-type StatusResponse struct {
-	Status string `json:"status"`
+// ErrorResponse - ответ с описанием ошибки
+type ErrorResponse struct {
+	Error string `json:"error"`
 }
 
-// TODO: Запуск сервера будет выполняться в пакете http. Нужно тут оставить Handle
-func SetupRoutes() {
-	http.HandleFunc("/status", handlerStatus)
+// PinRequest - запрос на закрепление коммита: по хешу либо на несколько ревизий назад
+type PinRequest struct {
+	Commit string `json:"commit,omitempty"` // Полный или сокращенный хеш коммита
+	Back   int    `json:"back,omitempty"`   // Количество ревизий назад от текущего коммита
 }
 
-func handlerStatus(w http.ResponseWriter, r *http.Request) {
+// StatusHandler возвращает обработчик, который выводит состояние синхронизации в формате JSON:
+// репозиторий, отслеживаемая ссылка, текущий коммит и список изменений файлов.
+func StatusHandler(provider interfaces.StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, provider.Status())
+	})
+}
+
+// Размер страницы истории синхронизаций по умолчанию и максимальный
+const (
+	historyLimit    = 20
+	historyMaxLimit = 500
+)
+
+// HistoryHandler возвращает обработчик, который выводит историю синхронизаций в формате JSON
+// от новых записей к старым. Параметры запроса: trigger, outcome, since и until (RFC 3339),
+// offset и limit.
+func HistoryHandler(provider interfaces.HistoryProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		query, err := readHistoryQuery(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, provider.History(query))
+	})
+}
+
+// readHistoryQuery читает параметры выборки из истории синхронизаций
+func readHistoryQuery(r *http.Request) (models.HistoryQuery, error) {
+
+	values := r.URL.Query()
+	query := models.HistoryQuery{
+		Trigger: values.Get("trigger"),
+		Outcome: values.Get("outcome"),
+		Limit:   historyLimit,
+	}
+
+	for name, value := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if v := values.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s value: %s", name, v)
+			}
+			*value = t
+		}
+	}
+
+	for name, value := range map[string]*int{"offset": &query.Offset, "limit": &query.Limit} {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("invalid %s value: %s", name, v)
+			}
+			*value = n
+		}
+	}
+	if query.Limit > historyMaxLimit {
+		query.Limit = historyMaxLimit
+	}
+
+	return query, nil
+}
+
+// PauseRequest - запрос на приостановку синхронизации
+type PauseRequest struct {
+	By       string `json:"by,omitempty"`       // Кто приостанавливает (по умолчанию пользователь basic-аутентификации)
+	Reason   string `json:"reason,omitempty"`   // Причина приостановки
+	Duration string `json:"duration,omitempty"` // Время до автоматического возобновления (например, 30m)
+}
+
+// PauseHandler возвращает обработчик приостановки синхронизации:
+// GET - текущая приостановка, POST - приостановить (PauseRequest в теле запроса
+// или параметры by, reason и duration).
+func PauseHandler(controller interfaces.Controller, provider interfaces.StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, provider.Status().Paused)
+
+		case http.MethodPost:
+			var request PauseRequest
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+					writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+					return
+				}
+			}
+			query := r.URL.Query()
+			for name, value := range map[string]*string{"by": &request.By, "reason": &request.Reason, "duration": &request.Duration} {
+				if query.Has(name) {
+					*value = query.Get(name)
+				}
+			}
+
+			var duration time.Duration
+			if request.Duration != "" {
+				var err error
+				duration, err = time.ParseDuration(request.Duration)
+				if err != nil || duration <= 0 {
+					writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid duration: %s", request.Duration)})
+					return
+				}
+			}
+
+			writeJSON(w, http.StatusOK, controller.Pause(requestUser(r, request.By), request.Reason, duration))
+
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+		}
+	})
+}
+
+// ResumeHandler возвращает обработчик возобновления синхронизации (POST).
+// В ответ выводится состояние синхронизации.
+func ResumeHandler(controller interfaces.Controller, provider interfaces.StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+			return
+		}
+
+		if !controller.Resume(requestUser(r, r.URL.Query().Get("by"))) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{Error: "synchronization is not paused"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, provider.Status())
+	})
+}
+
+// requestUser возвращает автора операции: указанного в запросе,
+// пользователя basic-аутентификации или адрес клиента
+func requestUser(r *http.Request, by string) string {
+	if by != "" {
+		return by
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return r.RemoteAddr
+}
+
+// PinHandler возвращает обработчик закрепления коммита:
+// GET - текущее закрепление, POST - закрепить коммит (PinRequest в теле запроса
+// или параметры commit и back), DELETE - снять закрепление.
+// В ответ на POST и DELETE выводится состояние синхронизации.
+func PinHandler(controller interfaces.Controller, provider interfaces.StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, provider.Status().Pin)
+
+		case http.MethodPost:
+			request, err := readPinRequest(r)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+
+			_, err = controller.Pin(r.Context(), request.Commit, request.Back)
+			if errors.Is(err, git.ErrInvalidPin) {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			if errors.Is(err, git.ErrDryRun) {
+				writeJSON(w, http.StatusConflict, ErrorResponse{Error: err.Error()})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, provider.Status())
+
+		case http.MethodDelete:
+			err := controller.Unpin(r.Context())
+			if errors.Is(err, git.ErrDryRun) {
+				writeJSON(w, http.StatusConflict, ErrorResponse{Error: err.Error()})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, provider.Status())
+
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+		}
+	})
+}
+
+// ApproveRequest - запрос на подтверждение ревизии, задержанной директивой в сообщении коммита
+type ApproveRequest struct {
+	Commit string `json:"commit,omitempty"` // Задержанный коммит (по умолчанию текущая задержанная ревизия)
+}
+
+// ApproveHandler возвращает обработчик подтверждения задержанной ревизии:
+// GET - задержанная ревизия, POST - подтвердить ее (ApproveRequest в теле запроса или параметр commit)
+// и сразу выполнить синхронизацию. В ответ на POST выводится состояние синхронизации.
+func ApproveHandler(controller interfaces.Controller, provider interfaces.StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, provider.Status().Held)
+
+		case http.MethodPost:
+			var request ApproveRequest
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+					writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+					return
+				}
+			}
+			if commit := r.URL.Query().Get("commit"); commit != "" {
+				request.Commit = commit
+			}
+
+			_, err := controller.Approve(r.Context(), request.Commit)
+			if errors.Is(err, git.ErrInvalidApproval) {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			if errors.Is(err, git.ErrDryRun) {
+				writeJSON(w, http.StatusConflict, ErrorResponse{Error: err.Error()})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, provider.Status())
+
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+		}
+	})
+}
+
+// readPinRequest читает запрос на закрепление из тела запроса (JSON) или параметров commit и back
+func readPinRequest(r *http.Request) (PinRequest, error) {
+
+	var request PinRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return request, fmt.Errorf("invalid request body: %v", err)
+		}
+	}
+
+	query := r.URL.Query()
+	if commit := query.Get("commit"); commit != "" {
+		request.Commit = commit
+	}
+	if back := query.Get("back"); back != "" {
+		value, err := strconv.Atoi(back)
+		if err != nil {
+			return request, fmt.Errorf("invalid back value: %s", back)
+		}
+		request.Back = value
+	}
+
+	if request.Commit != "" && request.Back != 0 {
+		return request, fmt.Errorf("commit and back are mutually exclusive")
+	}
+	if request.Commit == "" && request.Back <= 0 {
+		return request, fmt.Errorf("commit or a positive back value is required")
+	}
+
+	return request, nil
+}
 
-	var resp StatusResponse
+// writeJSON кодирует ответ в JSON и отправляет его с указанным статусом
+func writeJSON(w http.ResponseWriter, status int, response any) {
 
-	json.NewEncoder(w).Encode(resp)
+	data, err := json.Marshal(response)
+	if err != nil {
+		// В случае ошибки выводим сообщение об ошибке в текстовом формате
+		http.Error(w, fmt.Sprintf("JSON encoding error: %v", err), http.StatusInternalServerError)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
 }
@@ -0,0 +1,164 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"git-sync/api"
+	"git-sync/internal/constants"
+	"git-sync/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Команды управления запущенным сервисом
+const (
+	commandPin     = "pin"
+	commandUnpin   = "unpin"
+	commandPause   = "pause"
+	commandResume  = "resume"
+	commandApprove = "approve"
+)
+
+// isControlCommand проверяет, является ли аргумент командой управления
+func isControlCommand(arg string) bool {
+	switch arg {
+	case commandPin, commandUnpin, commandPause, commandResume, commandApprove:
+		return true
+	default:
+		return false
+	}
+}
+
+// runControl выполняет команду управления запущенным сервисом через HTTP API
+// и выводит ответ в стандартный вывод. Возвращает код завершения.
+//
+//	git-sync pin <commit>
+//	git-sync pin --back <N>
+//	git-sync unpin
+//	git-sync pause [--reason <text>] [--for <duration>] [--by <name>]
+//	git-sync resume [--by <name>]
+//	git-sync approve [<commit>]
+func runControl(command string, args []string) int {
+
+	fs := flag.NewFlagSet("git-sync "+command, flag.ContinueOnError)
+	addr := fs.String(constants.FlagHttpServerAddr, os.Getenv(constants.EnvHttpServerAddr), fmt.Sprintf("Адрес HTTP-сервера запущенного сервиса (%s)", constants.EnvHttpServerAddr))
+	username := fs.String(constants.FlagHttpServerAuthUsername, os.Getenv(constants.EnvHttpServerAuthUsername), fmt.Sprintf("Имя пользователя для basic-аутентификации (%s)", constants.EnvHttpServerAuthUsername))
+	password := fs.String(constants.FlagHttpServerAuthPassword, os.Getenv(constants.EnvHttpServerAuthPassword), fmt.Sprintf("Пароль для basic-аутентификации (%s)", constants.EnvHttpServerAuthPassword))
+	token := fs.String(constants.FlagHttpServerAuthToken, os.Getenv(constants.EnvHttpServerAuthToken), fmt.Sprintf("Токен для аутентификации (%s)", constants.EnvHttpServerAuthToken))
+	back := fs.Int("back", 0, "Закрепить коммит на N ревизий раньше текущего")
+	reason := fs.String("reason", "", "Причина приостановки синхронизации")
+	duration := fs.Duration("for", 0, "Время до автоматического возобновления синхронизации")
+	by := fs.String("by", os.Getenv("USER"), "Кто приостанавливает или возобновляет синхронизацию")
+
+	if err := fs.Parse(args); err != nil {
+		return constants.ExitValidation
+	}
+
+	var (
+		method = http.MethodPost
+		path   string
+		body   []byte
+	)
+	switch command {
+	case commandPin:
+		request := api.PinRequest{Commit: fs.Arg(0), Back: *back}
+		if (request.Commit == "") == (request.Back <= 0) {
+			logger.GetLogger().Error("usage: git-sync pin <commit> | git-sync pin --back <N>\n")
+			return constants.ExitValidation
+		}
+		path = "/pin"
+		body, _ = json.Marshal(request)
+	case commandUnpin:
+		method = http.MethodDelete
+		path = "/pin"
+	case commandPause:
+		request := api.PauseRequest{By: *by, Reason: *reason}
+		if *duration > 0 {
+			request.Duration = duration.String()
+		}
+		path = "/pause"
+		body, _ = json.Marshal(request)
+	case commandResume:
+		path = "/resume?" + url.Values{"by": {*by}}.Encode()
+	case commandApprove:
+		path = "/approve"
+		body, _ = json.Marshal(api.ApproveRequest{Commit: fs.Arg(0)})
+	}
+
+	if *addr == "" {
+		logger.GetLogger().Error("%s is not set\n", constants.FlagHttpServerAddr)
+		return constants.ExitValidation
+	}
+
+	req, err := http.NewRequest(method, "http://"+controlHost(*addr)+path, bytes.NewReader(body))
+	if err != nil {
+		logger.GetLogger().Error("%v\n", err)
+		return constants.ExitValidation
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	switch {
+	case *username != "" && *password != "":
+		req.SetBasicAuth(*username, *password)
+	case *token != "":
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.GetLogger().Error("%v\n", err)
+		return constants.ExitNetwork
+	}
+	defer resp.Body.Close()
+
+	io.Copy(os.Stdout, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return constants.ExitOK
+	case resp.StatusCode == http.StatusUnauthorized:
+		return constants.ExitAuth
+	case resp.StatusCode == http.StatusBadRequest, resp.StatusCode == http.StatusConflict:
+		return constants.ExitValidation
+	default:
+		logger.GetLogger().Error("%s %s: %s\n", method, req.URL, resp.Status)
+		return constants.ExitSyncError
+	}
+}
+
+// controlHost возвращает адрес для подключения к HTTP-серверу:
+// адрес прослушивания всех интерфейсов заменяется на локальный
+func controlHost(addr string) string {
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
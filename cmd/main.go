@@ -16,58 +16,85 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"git-sync/git"
+	"git-sync/internal/constants"
 	"git-sync/internal/flags"
 	"git-sync/internal/gitsync"
 	"git-sync/internal/http"
 	"git-sync/logger"
 	"os"
 	"os/signal"
+	"path/filepath"
 
 	"syscall"
 )
 
 func main() {
 
+	// Команды управления запущенным сервисом (git-sync pin, unpin, pause, resume)
+	if len(os.Args) > 1 && isControlCommand(os.Args[1]) {
+		os.Exit(runControl(os.Args[1], os.Args[2:]))
+	}
+
 	// Создаем контекст и функцию для отмены контекста
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	flagSet := flags.NewConsoleFlags()
 
+	oneTime := flags.LookupValue(flagSet.Gitsync, constants.FlagOneTime, false)
+	hashFile := flags.LookupValue(flagSet.Gitsync, constants.FlagHashFile, "")
+
+	// Хеш выводится в стандартный вывод, поэтому лог перенаправляется в stderr
+	if hashFile == "-" {
+		logger.GetLogger().SetOutput(os.Stderr)
+	}
+
 	// Проверка, были ли заданый обязательные флаги
 	if err := flagSet.CheckRequiredFlags(); err != nil {
 		logger.GetLogger().Error("%v", err)
-		fmt.Printf("\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		flagSet.Gitsync.SetOutput(os.Stderr)
 		flagSet.Gitsync.PrintDefaults()
-		os.Exit(0)
+		os.Exit(constants.ExitValidation)
 	}
 
 	// Проверка правильности заполнения флагов
 	if err := flagSet.ValidateFlags(); err != nil {
 		logger.GetLogger().Error("%v", err)
-		os.Exit(0)
+		os.Exit(constants.ExitValidation)
+	}
+
+	// Однократная синхронизация (init-контейнер, CI)
+	if oneTime {
+		go waitForSignals(cancel)
+		os.Exit(runOnce(ctx, flagSet, hashFile))
 	}
 
 	gitSync, err := gitsync.NewGitSync(flagSet.Gitsync, ctx)
 	if err != nil {
 		logger.GetLogger().Error("Error creating GitSync object: %v\n", err)
-		return
+		// Например, файл истории синхронизаций поврежден или недоступен для чтения
+		os.Exit(constants.ExitValidation)
 	}
 
-	gitRepo, err := git.NewGitRepository(flagSet.Gitsync)
+	gitRepo, err := git.NewGitRepository(flagSet.Gitsync, ctx)
 	if err != nil {
 		logger.GetLogger().Error("Error creating GitRepository object: %v\n", err)
-		return
+		os.Exit(exitCode(err))
 	}
 
 	// Запускаем http-сервер
-	http.StartServer(flagSet.Gitsync, ctx)
+	http.StartServer(flagSet.Gitsync, ctx, gitSync, gitSync, gitSync)
 
 	// Запускаем периодическую синхронизацию в отдельной горутине
 	go gitSync.Start(gitRepo)
 
+	// SIGUSR1 приостанавливает или возобновляет синхронизацию
+	go watchPauseSignal(ctx, gitSync)
+
 	// Ждем сигналов SIGINT или SIGTERM для завершения программы
 	waitForSignals(cancel)
 
@@ -75,6 +102,94 @@ func main() {
 	cancel()
 }
 
+// runOnce клонирует или синхронизирует репозиторий один раз, записывает хеш текущего коммита
+// в файл hashFile (если задан) и возвращает код завершения.
+func runOnce(ctx context.Context, flagSet *flags.ConsoleFlags, hashFile string) int {
+
+	gitRepo, err := git.NewGitRepository(flagSet.Gitsync, ctx)
+	if err == nil {
+		err = gitRepo.Sync(ctx)
+	}
+	if err != nil {
+		logger.GetLogger().Error("Sync error: %v\n", err)
+		return exitCode(err)
+	}
+
+	if hashFile != "" {
+		if err := writeHash(hashFile, gitRepo.CommitHash()); err != nil {
+			logger.GetLogger().Error("Error writing commit hash: %v\n", err)
+			return constants.ExitSyncError
+		}
+	}
+
+	logger.GetLogger().Info("Sync: one-time synchronization completed (%s)\n", gitRepo.CommitHash())
+
+	return constants.ExitOK
+}
+
+// exitCode возвращает код завершения для ошибки синхронизации
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidOptions):
+		return constants.ExitValidation
+	case git.IsAuthError(err):
+		return constants.ExitAuth
+	case git.IsNetworkError(err):
+		return constants.ExitNetwork
+	default:
+		return constants.ExitSyncError
+	}
+}
+
+// writeHash записывает хеш коммита в файл path или в стандартный вывод, если path равен "-".
+// Файл записывается атомарно: сначала во временный файл, который затем переименовывается.
+func writeHash(path, hash string) error {
+
+	if path == "-" {
+		_, err := fmt.Fprintln(os.Stdout, hash)
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(tmp, hash); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// watchPauseSignal приостанавливает синхронизацию по сигналу SIGUSR1 или возобновляет ее,
+// если она уже приостановлена
+func watchPauseSignal(ctx context.Context, gitSync *gitsync.GitSync) {
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGUSR1)
+	defer signal.Stop(signalChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signalChan:
+			gitSync.TogglePause("signal", sig.String())
+		}
+	}
+}
+
 // waitForSignals ожидает сигналы SIGINT или SIGTERM и вызывает функцию cancel для завершения программы.
 func waitForSignals(cancel context.CancelFunc) {
 
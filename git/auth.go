@@ -0,0 +1,179 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"git-sync/internal/constants"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// isSSH проверяет, используется ли для удаленного репозитория протокол SSH
+// (адреса вида ssh://host/path и [user@]host:path).
+func (gitRepo *GitRepository) isSSH() bool {
+	endpoint, err := transport.NewEndpoint(gitRepo.options.url)
+	if err != nil {
+		return false
+	}
+	return endpoint.Protocol == "ssh"
+}
+
+// authModeName возвращает способ аутентификации с учетом автоматического выбора:
+// SSH для ssh-адресов, basic при заданном пользователе, token при заданном токене.
+func (gitRepo *GitRepository) authModeName(user, token string) string {
+
+	if gitRepo.options.authMode != constants.AuthModeAuto {
+		return gitRepo.options.authMode
+	}
+
+	switch {
+	case gitRepo.isSSH():
+		return constants.AuthModeSSH
+	case user != "":
+		return constants.AuthModeBasic
+	case token != "":
+		return constants.AuthModeToken
+	default:
+		return constants.AuthModeNone
+	}
+}
+
+// needsCredentials проверяет, используются ли для аутентификации имя пользователя и токен
+func (gitRepo *GitRepository) needsCredentials() bool {
+	switch gitRepo.options.authMode {
+	case constants.AuthModeNone, constants.AuthModeSSH, constants.AuthModeGitHubApp:
+		return false
+	case constants.AuthModeAuto:
+		return !gitRepo.isSSH()
+	default:
+		return true
+	}
+}
+
+// authMethod возвращает метод аутентификации для операций с удаленным репозиторием.
+// Учетные данные получаются заново при каждом вызове. Для режима none возвращается nil.
+func (gitRepo *GitRepository) authMethod() (transport.AuthMethod, error) {
+
+	var user, token string
+	if gitRepo.needsCredentials() {
+		var err error
+		user, token, err = gitRepo.credentials()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch mode := gitRepo.authModeName(user, token); mode {
+	case constants.AuthModeNone:
+		return nil, nil
+	case constants.AuthModeToken:
+		return &http.TokenAuth{
+			Token: token, // Токен для аутентификации
+		}, nil
+	case constants.AuthModeBasic:
+		return &http.BasicAuth{
+			Username: user,
+			Password: token, // Пароль или токен
+		}, nil
+	case constants.AuthModeSSH:
+		return gitRepo.sshAuth()
+	case constants.AuthModeGitHubApp:
+		return gitRepo.gitHubAppAuth()
+	default:
+		return nil, fmt.Errorf("unknown authentication mode: %s", mode)
+	}
+}
+
+// sshAuth формирует метод аутентификации по SSH-ключу.
+// Ключ берется из параметра sshKey (содержимое) либо из файла sshKeyFile.
+// Если ключ не задан, используется ssh-agent.
+func (gitRepo *GitRepository) sshAuth() (transport.AuthMethod, error) {
+
+	endpoint, err := transport.NewEndpoint(gitRepo.options.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository URL: %v", err)
+	}
+
+	user := endpoint.User
+	if user == "" {
+		user = ssh.DefaultUsername
+	}
+
+	hostKeyCallback, err := gitRepo.sshHostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	pem := []byte(gitRepo.options.sshKey)
+	if len(pem) == 0 && gitRepo.options.sshKeyFile != "" {
+		pem, err = os.ReadFile(gitRepo.options.sshKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key file: %v", err)
+		}
+	}
+
+	// Ключ не задан, пробуем ssh-agent
+	if len(pem) == 0 {
+		auth, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("SSH key is not set and ssh-agent is not available: %v", err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+
+	passphrase := gitRepo.options.sshKeyPassphrase
+	if gitRepo.options.sshKeyPassphraseFile != "" {
+		passphrase, err = readSecretFile(gitRepo.options.sshKeyPassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auth, err := ssh.NewPublicKeys(user, pem, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH key: %v", err)
+	}
+	auth.HostKeyCallback = hostKeyCallback
+
+	return auth, nil
+}
+
+// sshHostKeyCallback возвращает функцию проверки ключа SSH-сервера.
+// В строгом режиме ключ сверяется с файлом known_hosts (по умолчанию ~/.ssh/known_hosts),
+// иначе проверка отключается.
+func (gitRepo *GitRepository) sshHostKeyCallback() (gossh.HostKeyCallback, error) {
+
+	if !gitRepo.options.sshStrictHostKey {
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+
+	var files []string
+	if gitRepo.options.sshKnownHosts != "" {
+		files = append(files, gitRepo.options.sshKnownHosts)
+	}
+
+	callback, err := ssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %v", err)
+	}
+
+	return callback, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"git-sync/internal/constants"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func TestAuthMethod(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		url      string
+		user     string
+		token    string
+		authMode string
+		expected string
+	}{
+		{"Auto without credentials", "https://example.com/repo.git", "", "", constants.AuthModeAuto, constants.AuthModeNone},
+		{"Auto with token", "https://example.com/repo.git", "", "token", constants.AuthModeAuto, constants.AuthModeToken},
+		{"Auto with user and token", "https://example.com/repo.git", "user", "token", constants.AuthModeAuto, constants.AuthModeBasic},
+		{"Auto with scp-like URL", "git@example.com:org/repo.git", "", "", constants.AuthModeAuto, constants.AuthModeSSH},
+		{"Auto with SSH URL", "ssh://git@example.com/org/repo.git", "user", "token", constants.AuthModeAuto, constants.AuthModeSSH},
+		{"Explicit token with user", "https://example.com/repo.git", "user", "token", constants.AuthModeToken, constants.AuthModeToken},
+		{"Explicit none", "https://example.com/repo.git", "user", "token", constants.AuthModeNone, constants.AuthModeNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitRepo := &GitRepository{
+				options: NewGitRepositoryOptions(tt.url, "master", t.TempDir(), tt.user, tt.token, "origin"),
+			}
+			gitRepo.options.authMode = tt.authMode
+
+			if mode := gitRepo.authModeName(tt.user, tt.token); mode != tt.expected {
+				t.Errorf("Expected auth mode '%s', got '%s'", tt.expected, mode)
+			}
+		})
+	}
+}
+
+func TestAuthMethodBasic(t *testing.T) {
+
+	gitRepo := &GitRepository{
+		options: NewGitRepositoryOptions("https://example.com/repo.git", "master", t.TempDir(), "user", "secret", "origin"),
+	}
+
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%v'", err)
+	}
+
+	basicAuth, ok := auth.(*http.BasicAuth)
+	if !ok {
+		t.Fatalf("Expected *http.BasicAuth, got %T", auth)
+	}
+
+	if basicAuth.Username != "user" || basicAuth.Password != "secret" {
+		t.Errorf("Unexpected credentials: %s/%s", basicAuth.Username, basicAuth.Password)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// Типы изменений файлов
+const (
+	ChangeInsert string = "insert" // файл добавлен
+	ChangeModify string = "modify" // файл изменен
+	ChangeDelete string = "delete" // файл удален
+	ChangeRename string = "rename" // файл переименован (возможно, с изменением)
+)
+
+// changesInfo преобразует изменения между деревьями коммитов в список ChangeInfo.
+// Пары удаление/добавление похожих файлов объединяются в переименования.
+// При частичном checkout изменения вне выбранных путей не учитываются.
+func (gitRepo *GitRepository) changesInfo(changes object.Changes) ([]ChangeInfo, error) {
+
+	changes, err := object.DetectRenames(changes, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect renames: %w", err)
+	}
+
+	info := make([]ChangeInfo, 0, len(changes))
+
+	for _, change := range changes {
+
+		if !gitRepo.inSparse(change.From.Name) && !gitRepo.inSparse(change.To.Name) {
+			continue
+		}
+
+		action, err := change.Action()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get change action: %v", err)
+		}
+
+		c := ChangeInfo{
+			FileName: change.To.Name,
+			FromHash: blobHash(change.From.TreeEntry.Hash),
+			ToHash:   blobHash(change.To.TreeEntry.Hash),
+		}
+
+		switch {
+		case action == merkletrie.Insert:
+			c.ChangeType = ChangeInsert
+		case action == merkletrie.Delete:
+			c.ChangeType = ChangeDelete
+			c.FileName = change.From.Name
+		case change.From.Name != change.To.Name:
+			c.ChangeType = ChangeRename
+			c.OldFileName = change.From.Name
+		default:
+			c.ChangeType = ChangeModify
+		}
+
+		info = append(info, c)
+	}
+
+	return info, nil
+}
+
+// blobHash возвращает строковое представление хеша или пустую строку для отсутствующего объекта
+func blobHash(hash plumbing.Hash) string {
+	if hash.IsZero() {
+		return ""
+	}
+	return hash.String()
+}
+
+// filterChanges применяет к изменениям фильтры изменений changesInclude и changesExclude:
+// изменения, не прошедшие фильтры, отмечаются как не учитываемые, для каждого изменения
+// записывается совпавший фильтр. Переименование учитывается, если фильтры проходит
+// новый или прежний путь. Возвращает количество учитываемых изменений.
+func (gitRepo *GitRepository) filterChanges(changes []ChangeInfo) int {
+
+	relevant := 0
+
+	for i := range changes {
+		change := &changes[i]
+
+		ok, filter := gitRepo.relevantPath(change.FileName)
+		if !ok && change.OldFileName != "" {
+			if oldOK, oldFilter := gitRepo.relevantPath(change.OldFileName); oldOK {
+				ok, filter = oldOK, oldFilter
+			}
+		}
+
+		change.Filter = filter
+		change.Ignored = !ok
+		if ok {
+			relevant++
+		}
+	}
+
+	return relevant
+}
+
+// RelevantChanges возвращает изменения файлов коммита, учитываемые фильтрами изменений
+func (commit *CommitInfo) RelevantChanges() []ChangeInfo {
+
+	var changes []ChangeInfo
+	for _, change := range commit.Changes {
+		if !change.Ignored {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// relevantPath проверяет, учитываются ли изменения пути name, и возвращает совпавший фильтр.
+// Путь учитывается, если соответствует одному из шаблонов changesInclude (или список пуст)
+// и не соответствует ни одному из шаблонов changesExclude.
+func (gitRepo *GitRepository) relevantPath(name string) (bool, string) {
+
+	filter := ""
+
+	if len(gitRepo.options.changesInclude) > 0 {
+		pattern, ok := matchAnyPath(gitRepo.options.changesInclude, name)
+		if !ok {
+			return false, ""
+		}
+		filter = "include:" + pattern
+	}
+
+	if pattern, ok := matchAnyPath(gitRepo.options.changesExclude, name); ok {
+		return false, "exclude:" + pattern
+	}
+
+	return true, filter
+}
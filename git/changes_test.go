@@ -0,0 +1,153 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitChanges(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add files", map[string]string{
+		"config.yaml": "key: value\n",
+		"old.txt":     "obsolete\n",
+		"moved.txt":   "line 1\nline 2\nline 3\nline 4\nline 5\n",
+	})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	gitRepo := newTestRepository(t, newTestFlags(t, remote, localPath))
+
+	// Переименование выполняется удалением и добавлением файла с тем же содержимым
+	remote.commit("change files", map[string]string{
+		"config.yaml":   "key: new value\n",
+		"old.txt":       "",
+		"new.txt":       "fresh\n",
+		"moved.txt":     "",
+		"dir/moved.txt": "line 1\nline 2\nline 3\nline 4\nline 5\n",
+	})
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	commit, err := gitRepo.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]git.ChangeInfo{
+		"config.yaml":   {ChangeType: git.ChangeModify, FileName: "config.yaml"},
+		"old.txt":       {ChangeType: git.ChangeDelete, FileName: "old.txt"},
+		"new.txt":       {ChangeType: git.ChangeInsert, FileName: "new.txt"},
+		"dir/moved.txt": {ChangeType: git.ChangeRename, FileName: "dir/moved.txt", OldFileName: "moved.txt"},
+	}
+
+	if len(commit.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), commit.Changes)
+	}
+
+	for _, change := range commit.Changes {
+		want, ok := expected[change.FileName]
+		if !ok {
+			t.Errorf("Unexpected change %+v", change)
+			continue
+		}
+		if change.ChangeType != want.ChangeType || change.OldFileName != want.OldFileName {
+			t.Errorf("Expected %+v, got %+v", want, change)
+		}
+
+		// Хеши объектов заполнены для существующих версий файла
+		switch change.ChangeType {
+		case git.ChangeInsert:
+			if change.FromHash != "" || change.ToHash == "" {
+				t.Errorf("Unexpected hashes for insert: %+v", change)
+			}
+		case git.ChangeDelete:
+			if change.FromHash == "" || change.ToHash != "" {
+				t.Errorf("Unexpected hashes for delete: %+v", change)
+			}
+		default:
+			if change.FromHash == "" || change.ToHash == "" {
+				t.Errorf("Unexpected hashes for %s: %+v", change.ChangeType, change)
+			}
+		}
+	}
+
+	// Повторная синхронизация без изменений сохраняет список изменений текущего коммита
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if commit, _ := gitRepo.Commit(); len(commit.Changes) != len(expected) {
+		t.Errorf("Expected changes of the current commit to be kept, got %+v", commit.Changes)
+	}
+}
+
+func TestChangesFilters(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.String(constants.FlagChangesInclude, "", "Include patterns")
+	mockFlags.String(constants.FlagChangesExclude, "", "Exclude patterns")
+	gitRepo := newTestRepository(t, mockFlags,
+		"--"+constants.FlagChangesInclude+"=config,*.md", "--"+constants.FlagChangesExclude+"=README.md")
+
+	// Изменения вне фильтров не считаются изменениями, но рабочий каталог переключается
+	hash := remote.commit("docs", map[string]string{"README.md": "docs\n", "app.txt": "app\n"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Error("Expected filtered changes not to be reported")
+	}
+	if gitRepo.CommitHash() != hash.String() {
+		t.Errorf("Expected checkout of %s, got %s", hash, gitRepo.CommitHash())
+	}
+
+	commit, err := gitRepo.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters := map[string]string{"README.md": "exclude:README.md", "app.txt": ""}
+	for _, change := range commit.Changes {
+		if !change.Ignored || change.Filter != filters[change.FileName] {
+			t.Errorf("Expected %s to be ignored by %q, got %+v", change.FileName, filters[change.FileName], change)
+		}
+	}
+
+	remote.commit("config", map[string]string{"config/app.yaml": "key: value\n", "app.txt": "app 2\n"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if !gitRepo.HasChanges() {
+		t.Error("Expected included changes to be reported")
+	}
+
+	commit, err = gitRepo.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range commit.Changes {
+		if change.FileName == "config/app.yaml" && (change.Ignored || change.Filter != "include:config") {
+			t.Errorf("Expected config/app.yaml to match include:config, got %+v", change)
+		}
+	}
+}
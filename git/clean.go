@@ -0,0 +1,135 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"git-sync/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// cleanRepo удаляет из рабочего каталога неотслеживаемые файлы и ставшие пустыми каталоги.
+// Игнорируемые файлы (.gitignore) удаляются, только если не установлен cleanKeepIgnored.
+// Файлы, соответствующие защищенным шаблонам, не удаляются.
+// Возвращает отсортированный список удаленных файлов.
+func (gitRepo *GitRepository) cleanRepo(status git.Status) ([]string, error) {
+
+	idx, err := gitRepo.repository.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %v", err)
+	}
+
+	tracked := make(map[string]bool, len(idx.Entries))
+	submodules := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		tracked[entry.Name] = true
+		if entry.Mode == filemode.Submodule {
+			submodules[entry.Name] = true
+		}
+	}
+
+	root := gitRepo.options.path
+
+	var (
+		removed []string
+		dirs    []string
+	)
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// Служебный каталог и подмодули не очищаются
+			if name == git.GitDirName || submodules[name] {
+				return filepath.SkipDir
+			}
+			if _, ok := matchAnyPath(gitRepo.options.cleanProtected, name); ok {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, p)
+			return nil
+		}
+
+		if tracked[name] {
+			return nil
+		}
+		if _, ok := matchAnyPath(gitRepo.options.cleanProtected, name); ok {
+			return nil
+		}
+
+		// Игнорируемые файлы не попадают в статус как неотслеживаемые
+		if fileStatus, ok := status[name]; gitRepo.options.cleanKeepIgnored && (!ok || fileStatus.Worktree != git.Untracked) {
+			return nil
+		}
+
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", name, err)
+		}
+		removed = append(removed, name)
+
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to clean worktree: %v", err)
+	}
+
+	// Удаляем пустые каталоги, начиная с самых вложенных
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(filepath.Separator)) > strings.Count(dirs[j], string(filepath.Separator))
+	})
+	for _, dir := range dirs {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			os.Remove(dir)
+		}
+	}
+
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		logger.GetLogger().Info("clean %s\n", name)
+	}
+
+	return removed, nil
+}
+
+// untrackedFiles возвращает отсортированный список неотслеживаемых файлов рабочего каталога
+func untrackedFiles(status git.Status) []string {
+
+	var files []string
+	for name, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			files = append(files, name)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newCleanRepository клонирует удаленный репозиторий с флагами очистки
+// и добавляет в рабочий каталог посторонние файлы.
+func newCleanRepository(t *testing.T, remote *remoteRepo, args ...string) (*git.GitRepository, string) {
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagClean, false, "Clean untracked files")
+	mockFlags.Bool(constants.FlagCleanKeepIgnored, true, "Keep ignored files")
+	mockFlags.String(constants.FlagCleanProtected, "", "Protected paths")
+
+	gitRepo := newTestRepository(t, mockFlags, args...)
+
+	for _, name := range []string{"stray.txt", "dir/nested/stray.txt", "app.log", "keep/data.txt"} {
+		fullPath := filepath.Join(localPath, name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte("stray"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return gitRepo, localPath
+}
+
+func TestCleanUntrackedFiles(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add gitignore", map[string]string{".gitignore": "*.log\n"})
+
+	exists := func(localPath, name string) bool {
+		_, err := os.Stat(filepath.Join(localPath, name))
+		return err == nil
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		gitRepo, localPath := newCleanRepository(t, remote,
+			"--"+constants.FlagClean, "--"+constants.FlagCleanProtected+"=keep")
+
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		if !gitRepo.HasChanges() {
+			t.Error("Expected removal of untracked files to be reported as changes")
+		}
+		for _, name := range []string{"stray.txt", "dir"} {
+			if exists(localPath, name) {
+				t.Errorf("Expected %s to be removed", name)
+			}
+		}
+		for _, name := range []string{"app.log", "keep/data.txt", "README.md", ".gitignore"} {
+			if !exists(localPath, name) {
+				t.Errorf("Expected %s to be kept", name)
+			}
+		}
+
+		// Повторная синхронизация не находит изменений
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		if gitRepo.HasChanges() {
+			t.Error("Expected no changes after cleaning")
+		}
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		gitRepo, localPath := newCleanRepository(t, remote,
+			"--"+constants.FlagClean, "--"+constants.FlagCleanKeepIgnored+"=false")
+
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		for _, name := range []string{"app.log", "keep"} {
+			if exists(localPath, name) {
+				t.Errorf("Expected %s to be removed", name)
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		gitRepo, localPath := newCleanRepository(t, remote)
+
+		// Неотслеживаемые файлы не считаются изменениями и не удаляются
+		for i := 0; i < 2; i++ {
+			if err := gitRepo.Sync(context.Background()); err != nil {
+				t.Fatalf("Error syncing repository: %v", err)
+			}
+			if gitRepo.HasChanges() {
+				t.Error("Expected untracked files not to be reported as changes")
+			}
+		}
+		if !exists(localPath, "stray.txt") {
+			t.Error("Expected stray.txt to be kept")
+		}
+	})
+}
+
+func TestCleanDoesNotRepeatRemoteChanges(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	gitRepo, localPath := newCleanRepository(t, remote, "--"+constants.FlagClean)
+
+	// Первая синхронизация применяет изменения удаленного репозитория и очищает каталог
+	remote.commit("update", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	// Вторая синхронизация только удаляет неотслеживаемый файл
+	if err := os.WriteFile(filepath.Join(localPath, "stray.txt"), []byte("stray"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	commit, err := gitRepo.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gitRepo.HasChanges() || commit.Reason != "clean" || len(commit.Changes) != 0 {
+		t.Errorf("Expected clean without remote changes, got has=%v reason=%s changes=%d",
+			gitRepo.HasChanges(), commit.Reason, len(commit.Changes))
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func TestCredentialsFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "user")
+	tokenFile := filepath.Join(dir, "token")
+
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Error writing %s: %v", path, err)
+		}
+	}
+
+	write(userFile, "user\n")
+	write(tokenFile, "first\n")
+
+	gitRepo := &GitRepository{
+		options: NewGitRepositoryOptions("https://example.com/repo.git", "master", dir, "", "", "origin"),
+	}
+	gitRepo.options.userFile = userFile
+	gitRepo.options.tokenFile = tokenFile
+
+	check := func(expected string) {
+		t.Helper()
+		auth, err := gitRepo.authMethod()
+		if err != nil {
+			t.Fatalf("Expected no error, got '%v'", err)
+		}
+		basicAuth, ok := auth.(*http.BasicAuth)
+		if !ok {
+			t.Fatalf("Expected *http.BasicAuth, got %T", auth)
+		}
+		if basicAuth.Username != "user" || basicAuth.Password != expected {
+			t.Errorf("Unexpected credentials: %s/%s", basicAuth.Username, basicAuth.Password)
+		}
+	}
+
+	check("first")
+
+	// Новый токен используется без пересоздания репозитория
+	write(tokenFile, "second")
+	check("second")
+
+	os.Remove(tokenFile)
+	if _, err := gitRepo.authMethod(); err == nil {
+		t.Errorf("Expected error for missing token file")
+	}
+}
+
+func TestNetrcCredentials(t *testing.T) {
+
+	netrc := filepath.Join(t.TempDir(), ".netrc")
+	content := `# comment
+machine git.example.com login alice password secret1
+macdef init
+machine fake.example.com login mallory password evil
+
+machine other.example.com
+  login bob
+  password secret2
+default login anonymous password guest
+`
+	if err := os.WriteFile(netrc, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing netrc: %v", err)
+	}
+
+	tests := []struct {
+		host     string
+		user     string
+		password string
+	}{
+		{"git.example.com", "alice", "secret1"},
+		{"other.example.com", "bob", "secret2"},
+		{"fake.example.com", "anonymous", "guest"},
+		{"unknown.example.com", "anonymous", "guest"},
+	}
+
+	for _, tt := range tests {
+		user, password, err := netrcCredentials(netrc, tt.host)
+		if err != nil {
+			t.Fatalf("Error reading netrc: %v", err)
+		}
+		if user != tt.user || password != tt.password {
+			t.Errorf("Expected %s/%s for %s, got %s/%s", tt.user, tt.password, tt.host, user, password)
+		}
+	}
+
+	gitRepo := &GitRepository{
+		options: NewGitRepositoryOptions("https://git.example.com/org/repo.git", "master", t.TempDir(), "", "", "origin"),
+	}
+	gitRepo.options.netrc = netrc
+
+	user, token, err := gitRepo.credentials()
+	if err != nil || user != "alice" || token != "secret1" {
+		t.Errorf("Expected alice/secret1 from netrc, got %s/%s (%v)", user, token, err)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+
+	// Путь к программе с пробелом не разбивается оболочкой
+	helper := filepath.Join(t.TempDir(), "credential helper.sh")
+	script := `#!/bin/sh
+[ "$1" = "get" ] || exit 1
+while read -r line; do
+  [ -z "$line" ] && break
+  case "$line" in
+    host=*) host=${line#host=} ;;
+    path=*) path=${line#path=} ;;
+  esac
+done
+echo "username=user-$host"
+echo "password=token-$path"
+`
+	if err := os.WriteFile(helper, []byte(script), 0755); err != nil {
+		t.Fatalf("Error writing helper: %v", err)
+	}
+
+	gitRepo := &GitRepository{
+		options: NewGitRepositoryOptions("https://git.example.com:8443/org/repo.git", "master", t.TempDir(), "", "", "origin"),
+	}
+	gitRepo.options.credentialHelper = helper
+
+	user, token, err := gitRepo.credentials()
+	if err != nil {
+		t.Fatalf("Error getting credentials: %v", err)
+	}
+	if user != "user-git.example.com:8443" || token != "token-org/repo.git" {
+		t.Errorf("Unexpected credentials from helper: %s/%s", user, token)
+	}
+
+	// Команда оболочки получает операцию get в аргументах
+	gitRepo.options.credentialHelper = "!f() { [ \"$1\" = get ] || exit 1; echo username=shell; echo password=secret; }; f"
+	user, token, err = gitRepo.credentials()
+	if err != nil || user != "shell" || token != "secret" {
+		t.Errorf("Expected shell/secret, got %s/%s (%v)", user, token, err)
+	}
+
+	// Явно заданные учетные данные имеют приоритет
+	gitRepo.options.token = "explicit"
+	if _, token, _ := gitRepo.credentials(); token != "explicit" {
+		t.Errorf("Expected explicit token, got %s", token)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/logger"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Директивы в сообщении коммита
+const (
+	DirectiveSkip string = "skip sync" // не применять ревизию
+	DirectiveHold string = "hold"      // применить ревизию после подтверждения
+)
+
+// approvalRefPrefix - префикс ссылок на коммиты, подтвержденные для применения (по ссылке на коммит).
+// Ссылки сохраняются в локальном репозитории, поэтому подтверждения действуют и после перезапуска.
+const approvalRefPrefix = "refs/git-sync/approvals/"
+
+// ErrInvalidApproval возвращается, если нет задержанной ревизии или подтверждается другой коммит
+var ErrInvalidApproval = errors.New("invalid approval")
+
+// directiveRegexp находит директиву в сообщении коммита: [skip sync], [hold] или [hold: причина]
+var directiveRegexp = regexp.MustCompile(`(?i)\[\s*(skip sync|hold)\s*(?::([^\]]*))?\]`)
+
+// HoldInfo содержит информацию о ревизии, задержанной директивой в сообщении коммита
+type HoldInfo struct {
+	Commit    string    `json:"commit"`           // Задержанный коммит отслеживаемой ссылки
+	Directive string    `json:"directive"`        // Директива (skip sync, hold)
+	Reason    string    `json:"reason,omitempty"` // Причина, указанная в директиве
+	Message   string    `json:"message"`          // Сообщение коммита
+	Since     time.Time `json:"since"`            // Время обнаружения ревизии
+}
+
+// parseDirective возвращает директиву и причину из сообщения коммита.
+// Если директив несколько, используется первая.
+func parseDirective(message string) (string, string, bool) {
+
+	match := directiveRegexp.FindStringSubmatch(message)
+	if match == nil {
+		return "", "", false
+	}
+
+	return strings.ToLower(match[1]), strings.TrimSpace(match[2]), true
+}
+
+// holdRevision проверяет директивы в сообщениях коммитов между локальным коммитом local
+// и коммитом отслеживаемой ссылки remote. Возвращает true, если ревизия не должна применяться:
+// один из новых коммитов ожидает подтверждения ([hold]) или коммит remote пропускается ([skip sync]).
+// Задерживается самый старый неподтвержденный коммит с [hold]; [skip sync] действует только
+// для последнего коммита, поэтому следующая ревизия без директивы применяется.
+func (gitRepo *GitRepository) holdRevision(ctx context.Context, local, remote *object.Commit) (bool, error) {
+
+	if !gitRepo.options.commitDirectives {
+		return false, nil
+	}
+
+	var commits []*object.Commit
+	err := gitRepo.withHistory(ctx, func() (err error) {
+		commits, err = newCommits(local, remote)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range commits {
+
+		directive, reason, ok := parseDirective(c.Message)
+		if !ok || gitRepo.approved(c.Hash) {
+			continue
+		}
+
+		if directive == DirectiveHold || c.Hash == remote.Hash {
+			gitRepo.hold(NewCommitInfo(c), directive, reason)
+			return true, nil
+		}
+	}
+
+	gitRepo.setHeld(nil)
+	return false, nil
+}
+
+// hold задерживает ревизию commit по директиве directive
+func (gitRepo *GitRepository) hold(commit *CommitInfo, directive, reason string) {
+
+	// Ревизия уже задержана при предыдущей синхронизации
+	if held := gitRepo.Held(); held != nil && held.Commit == commit.Hash {
+		return
+	}
+
+	gitRepo.setHeld(&HoldInfo{
+		Commit:    commit.Hash,
+		Directive: directive,
+		Reason:    reason,
+		Message:   strings.TrimSpace(commit.Message),
+		Since:     time.Now(),
+	})
+
+	if directive == DirectiveSkip {
+		logger.GetLogger().Warning("revision %s is skipped by [%s] in the commit message\n", commit.Hash, directive)
+	} else {
+		logger.GetLogger().Warning("revision %s is held by [%s] in the commit message until approved\n", commit.Hash, directive)
+	}
+}
+
+// Отметки коммитов при поиске новых коммитов
+const (
+	reachableLocal  uint8 = 1 << iota // коммит достижим из локального коммита
+	reachableRemote                   // коммит достижим из коммита отслеживаемой ссылки
+)
+
+// newCommits возвращает коммиты, достижимые из remote и недостижимые из local, от старых к новым.
+// Коммиты обходятся от новых к старым по времени коммита, как при поиске merge-base в git:
+// обход останавливается, когда все оставшиеся коммиты достижимы из local, поэтому история
+// старше общего предка не читается. Коммиты за границей неполной истории не учитываются.
+func newCommits(local, remote *object.Commit) ([]*object.Commit, error) {
+
+	marks := map[plumbing.Hash]uint8{}
+	queue := &commitQueue{}
+
+	push := func(c *object.Commit, mark uint8) {
+		if marks[c.Hash]&mark == mark {
+			return
+		}
+		marks[c.Hash] |= mark
+		heap.Push(queue, c)
+	}
+
+	push(local, reachableLocal)
+	push(remote, reachableRemote)
+
+	var commits []*object.Commit
+	for queue.Len() > 0 && queue.interesting(marks) {
+
+		c := heap.Pop(queue).(*object.Commit)
+		mark := marks[c.Hash]
+
+		for i := range c.ParentHashes {
+			parent, err := c.Parent(i)
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to walk commits: %w", err)
+			}
+			push(parent, mark)
+		}
+
+		if mark == reachableRemote {
+			commits = append(commits, c)
+		}
+	}
+
+	// Коммит мог оказаться достижимым из local позже (при неточном времени коммитов)
+	var result []*object.Commit
+	seen := map[plumbing.Hash]bool{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		if marks[c.Hash]&reachableLocal == 0 && !seen[c.Hash] {
+			seen[c.Hash] = true
+			result = append(result, c)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Committer.When.Before(result[j].Committer.When)
+	})
+
+	return result, nil
+}
+
+// commitQueue - очередь коммитов, упорядоченная от новых к старым по времени коммита
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int            { return len(q) }
+func (q commitQueue) Less(i, j int) bool  { return q[i].Committer.When.After(q[j].Committer.When) }
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*object.Commit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// interesting проверяет, остались ли в очереди коммиты, не достижимые из локального коммита
+func (q commitQueue) interesting(marks map[plumbing.Hash]uint8) bool {
+	for _, c := range q {
+		if marks[c.Hash]&reachableLocal == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalRef возвращает имя ссылки на подтвержденный коммит hash
+func approvalRef(hash plumbing.Hash) plumbing.ReferenceName {
+	return plumbing.ReferenceName(approvalRefPrefix + hash.String())
+}
+
+// approved проверяет, подтверждено ли применение коммита hash
+func (gitRepo *GitRepository) approved(hash plumbing.Hash) bool {
+	_, err := gitRepo.repository.Reference(approvalRef(hash), false)
+	return err == nil
+}
+
+// clearApprovals удаляет подтверждения после переключения на коммит отслеживаемой ссылки:
+// все подтвержденные коммиты к этому времени применены
+func (gitRepo *GitRepository) clearApprovals() error {
+
+	refs, err := gitRepo.repository.References()
+	if err != nil {
+		return fmt.Errorf("failed to get references: %v", err)
+	}
+
+	var names []plumbing.ReferenceName
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), approvalRefPrefix) {
+			names = append(names, ref.Name())
+		}
+		return nil
+	})
+
+	for _, name := range names {
+		if err := gitRepo.repository.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("failed to remove approval reference: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// setHeld сохраняет информацию о задержанной ревизии (nil - ревизия не задержана)
+func (gitRepo *GitRepository) setHeld(held *HoldInfo) {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	gitRepo.held = held
+}
+
+// Held возвращает информацию о ревизии, задержанной директивой в сообщении коммита, или nil
+func (gitRepo *GitRepository) Held() *HoldInfo {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	return gitRepo.held
+}
+
+// Approve подтверждает применение задержанной ревизии. Если commit не пуст, он должен
+// совпадать с задержанным коммитом (допускается сокращенный хеш). Ревизия применяется
+// при следующей синхронизации.
+func (gitRepo *GitRepository) Approve(commit string) (*HoldInfo, error) {
+
+	if gitRepo.options.dryRun {
+		return nil, fmt.Errorf("approve: %w", ErrDryRun)
+	}
+
+	held := gitRepo.Held()
+	if held == nil {
+		return nil, fmt.Errorf("%w: no revision is held", ErrInvalidApproval)
+	}
+
+	if commit != "" && !strings.HasPrefix(held.Commit, strings.ToLower(commit)) {
+		return nil, fmt.Errorf("%w: held revision is %s, not %s", ErrInvalidApproval, held.Commit, commit)
+	}
+
+	hash := plumbing.NewHash(held.Commit)
+	ref := plumbing.NewHashReference(approvalRef(hash), hash)
+	if err := gitRepo.repository.Storer.SetReference(ref); err != nil {
+		return nil, fmt.Errorf("failed to store approval reference: %v", err)
+	}
+
+	logger.GetLogger().Info("revision %s approved\n", held.Commit)
+
+	return held, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitDirectives(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagCommitDirectives, true, "Commit message directives")
+	gitRepo := newTestRepository(t, mockFlags)
+
+	sync := func() {
+		t.Helper()
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+	}
+
+	initial := gitRepo.CommitHash()
+
+	// [skip sync] - ревизия не применяется, следующая ревизия без директивы применяется
+	remote.commit("wip [skip sync]", map[string]string{"app.txt": "wip\n"})
+	sync()
+	if gitRepo.CommitHash() != initial {
+		t.Errorf("Expected skipped revision not to be applied")
+	}
+	if held := gitRepo.Held(); held == nil || held.Directive != git.DirectiveSkip {
+		t.Errorf("Expected skipped revision to be reported, got %+v", held)
+	}
+
+	next := remote.commit("release", map[string]string{"app.txt": "release\n"})
+	sync()
+	if gitRepo.CommitHash() != next.String() || gitRepo.Held() != nil {
+		t.Errorf("Expected revision %s to be applied, got %s", next, gitRepo.CommitHash())
+	}
+
+	// [hold] - ревизия применяется после подтверждения
+	held := remote.commit("migrate\n\n[HOLD: wait for the DB migration]", map[string]string{"app.txt": "migrated\n"})
+	sync()
+	sync()
+	if gitRepo.CommitHash() != next.String() {
+		t.Errorf("Expected held revision not to be applied")
+	}
+	info := gitRepo.Held()
+	if info == nil || info.Commit != held.String() || info.Directive != git.DirectiveHold || info.Reason != "wait for the DB migration" {
+		t.Fatalf("Expected held revision %s, got %+v", held, info)
+	}
+
+	if _, err := gitRepo.Approve(next.String()); !errors.Is(err, git.ErrInvalidApproval) {
+		t.Errorf("Expected approval of another commit to fail, got %v", err)
+	}
+	if _, err := gitRepo.Approve(held.String()[:7]); err != nil {
+		t.Fatalf("Error approving revision: %v", err)
+	}
+
+	sync()
+	if gitRepo.CommitHash() != held.String() || gitRepo.Held() != nil {
+		t.Errorf("Expected approved revision %s to be applied, got %s", held, gitRepo.CommitHash())
+	}
+	if _, err := gitRepo.Approve(""); !errors.Is(err, git.ErrInvalidApproval) {
+		t.Errorf("Expected approval without held revision to fail, got %v", err)
+	}
+}
+
+func TestCommitDirectivesDisabled(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagCommitDirectives, true, "Commit message directives")
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagCommitDirectives+"=false")
+
+	hash := remote.commit("[hold]", map[string]string{"app.txt": "app\n"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != hash.String() || gitRepo.Held() != nil {
+		t.Errorf("Expected directives to be ignored")
+	}
+}
+
+func TestCommitDirectivesHoldBeforeNewerCommit(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagCommitDirectives, true, "Commit message directives")
+	gitRepo := newTestRepository(t, mockFlags)
+
+	initial := gitRepo.CommitHash()
+
+	// Обычный коммит после [hold] не должен применять задержанную ревизию
+	held := remote.commit("migrate [hold]", map[string]string{"app.txt": "migrated\n"})
+	next := remote.commit("fix typo", map[string]string{"README.md": "fixed\n"})
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != initial {
+		t.Errorf("Expected revision with held commit not to be applied, got %s", gitRepo.CommitHash())
+	}
+	if info := gitRepo.Held(); info == nil || info.Commit != held.String() {
+		t.Fatalf("Expected held revision %s, got %+v", held, info)
+	}
+
+	if _, err := gitRepo.Approve(held.String()); err != nil {
+		t.Fatalf("Error approving revision: %v", err)
+	}
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != next.String() || gitRepo.Held() != nil {
+		t.Errorf("Expected revision %s to be applied after approval, got %s", next, gitRepo.CommitHash())
+	}
+}
+
+func TestCommitDirectivesSeveralHolds(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagCommitDirectives, true, "Commit message directives")
+	gitRepo := newTestRepository(t, mockFlags)
+
+	sync := func() {
+		t.Helper()
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+	}
+
+	first := remote.commit("migrate [hold]", map[string]string{"app.txt": "migrated\n"})
+	second := remote.commit("switch [hold]", map[string]string{"app.txt": "switched\n"})
+
+	// Подтверждения коммитов с [hold] не отменяют друг друга
+	for _, hash := range []string{first.String(), second.String()} {
+		sync()
+		if info := gitRepo.Held(); info == nil || info.Commit != hash {
+			t.Fatalf("Expected held revision %s, got %+v", hash, info)
+		}
+		if _, err := gitRepo.Approve(hash); err != nil {
+			t.Fatalf("Error approving revision: %v", err)
+		}
+	}
+
+	sync()
+	if gitRepo.CommitHash() != second.String() || gitRepo.Held() != nil {
+		t.Errorf("Expected approved revision %s to be applied, got %s", second, gitRepo.CommitHash())
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"git-sync/internal/constants"
+	"git-sync/logger"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrDryRun возвращается операциями управления (закрепление, подтверждение), которые изменили бы
+// рабочий каталог или ссылки локального репозитория в режиме dry-run
+var ErrDryRun = errors.New("not allowed in dry-run mode")
+
+// DryRunInfo содержит действия, которые выполнила бы синхронизация в режиме dry-run
+type DryRunInfo struct {
+	Commit       string       `json:"commit"`                 // Текущий коммит
+	Remote       string       `json:"remote,omitempty"`       // Коммит отслеживаемой ссылки
+	Update       string       `json:"update,omitempty"`       // Вид обновления (fast-forward, rewrite, rollback)
+	Action       string       `json:"action,omitempty"`       // Политика для обновления (follow, refuse, alert)
+	Changes      []ChangeInfo `json:"changes"`                // Изменения файлов при переключении на коммит отслеживаемой ссылки
+	LocalChanges []string     `json:"local_changes"`          // Локальные изменения
+	LocalPolicy  string       `json:"local_policy,omitempty"` // Обработка локальных изменений (reset, backup, stash, refuse, push)
+	Clean        []string     `json:"clean,omitempty"`        // Неотслеживаемые файлы, которые были бы удалены
+	Time         time.Time    `json:"time"`
+}
+
+// resetDryRun начинает новый отчет dry-run перед синхронизацией
+func (gitRepo *GitRepository) resetDryRun() {
+
+	var plan *DryRunInfo
+	if gitRepo.options.dryRun {
+		plan = &DryRunInfo{
+			Commit:       gitRepo.CommitHash(),
+			Changes:      []ChangeInfo{},
+			LocalChanges: []string{},
+			Time:         time.Now(),
+		}
+	}
+
+	gitRepo.mutex.Lock()
+	gitRepo.dryRun = plan
+	gitRepo.mutex.Unlock()
+}
+
+// DryRun возвращает отчет последней синхронизации в режиме dry-run или nil, если режим не включен
+func (gitRepo *GitRepository) DryRun() *DryRunInfo {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	return gitRepo.dryRun
+}
+
+// planLocalChanges записывает в отчет dry-run локальные изменения и неотслеживаемые файлы,
+// которые были бы удалены очисткой
+func (gitRepo *GitRepository) planLocalChanges(changedFiles, clean []string) {
+
+	policy := gitRepo.options.localChanges
+	if gitRepo.options.push {
+		policy = "push"
+	}
+
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+
+	if changedFiles != nil {
+		gitRepo.dryRun.LocalChanges = changedFiles
+	}
+	gitRepo.dryRun.LocalPolicy = policy
+	gitRepo.dryRun.Clean = clean
+}
+
+// planUpdate определяет вид обновления до коммита remote и политику для него без ее применения
+func (gitRepo *GitRepository) planUpdate(ctx context.Context, local, remote *object.Commit) error {
+
+	kind, err := gitRepo.classifyUpdate(ctx, local, remote)
+	if err != nil {
+		return err
+	}
+
+	action := gitRepo.updatePolicy(kind)
+
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+
+	gitRepo.dryRun.Remote = remote.Hash.String()
+	gitRepo.dryRun.Update = kind
+	gitRepo.dryRun.Action = action
+
+	return nil
+}
+
+// planRemoteChanges записывает в отчет dry-run коммит отслеживаемой ссылки и изменения файлов
+func (gitRepo *GitRepository) planRemoteChanges(remote string, changes []ChangeInfo) {
+
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+
+	gitRepo.dryRun.Remote = remote
+	if changes != nil {
+		gitRepo.dryRun.Changes = changes
+	}
+}
+
+// syncDryRun определяет локальные изменения (compareFiles) и изменения отслеживаемой ссылки
+// (compareCommitTrees), не изменяя рабочий каталог, и выводит отчет в лог.
+// Локальные изменения не отправляются, подмодули и публикуемая ревизия не обновляются.
+func (gitRepo *GitRepository) syncDryRun(ctx context.Context, pinned bool) error {
+
+	defer gitRepo.showDryRun()
+
+	if err := gitRepo.compareFiles(ctx); err != nil {
+		return err
+	}
+
+	if pinned {
+		return nil
+	}
+
+	return gitRepo.compareCommitTrees(ctx)
+}
+
+// cleanCandidates возвращает неотслеживаемые файлы, которые удалила бы очистка (cleanRepo).
+// Игнорируемые файлы не учитываются.
+func (gitRepo *GitRepository) cleanCandidates(status git.Status) []string {
+
+	var files []string
+	for _, name := range untrackedFiles(status) {
+		if _, ok := matchAnyPath(gitRepo.options.cleanProtected, name); !ok {
+			files = append(files, name)
+		}
+	}
+
+	return files
+}
+
+// showDryRun выводит в лог действия, которые выполнила бы синхронизация
+func (gitRepo *GitRepository) showDryRun() {
+
+	plan := gitRepo.DryRun()
+	if plan == nil {
+		return
+	}
+
+	log := logger.GetLogger()
+
+	if plan.Update != "" {
+		log.Warning("dry-run: would %s update %s %s..%s (%d file(s))\n", plan.Action, plan.Update, plan.Commit, plan.Remote, len(plan.Changes))
+		if plan.Action != constants.UpdateRefuse {
+			for _, change := range plan.Changes {
+				log.Info("dry-run:   %s %s\n", change.ChangeType, change.FileName)
+			}
+		}
+	}
+
+	if len(plan.LocalChanges) > 0 {
+		log.Warning("dry-run: would %s %d local change(s)\n", plan.LocalPolicy, len(plan.LocalChanges))
+		for _, name := range plan.LocalChanges {
+			log.Info("dry-run:   %s\n", name)
+		}
+	}
+
+	for _, name := range plan.Clean {
+		log.Warning("dry-run: would clean %s\n", name)
+	}
+
+	if plan.Update == "" && len(plan.LocalChanges) == 0 && len(plan.Clean) == 0 {
+		log.Info("dry-run: no pending changes at %s\n", plan.Commit)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagDryRun, false, "Dry run")
+	mockFlags.Bool(constants.FlagClean, false, "Clean untracked files")
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagDryRun, "--"+constants.FlagClean)
+
+	before := gitRepo.CommitHash()
+
+	// Локальные изменения, неотслеживаемый файл и новый коммит в удаленном репозитории
+	if err := os.WriteFile(filepath.Join(localPath, "README.md"), []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(localPath, "stray.txt"), []byte("stray"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	remoteHash := remote.commit("add app", map[string]string{"app.txt": "app"})
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	plan := gitRepo.DryRun()
+	if plan == nil {
+		t.Fatal("Expected dry-run report")
+	}
+	if plan.Commit != before || plan.Remote != remoteHash.String() {
+		t.Errorf("Expected update %s..%s, got %s..%s", before, remoteHash, plan.Commit, plan.Remote)
+	}
+	if plan.Update != git.UpdateFastForward || plan.Action != constants.UpdateFollow {
+		t.Errorf("Expected fast-forward follow, got %s %s", plan.Update, plan.Action)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].FileName != "app.txt" {
+		t.Errorf("Expected app.txt to be inserted, got %+v", plan.Changes)
+	}
+	if !reflect.DeepEqual(plan.LocalChanges, []string{"README.md"}) || plan.LocalPolicy != constants.LocalChangesReset {
+		t.Errorf("Expected README.md to be reset, got %v %s", plan.LocalChanges, plan.LocalPolicy)
+	}
+	if !reflect.DeepEqual(plan.Clean, []string{"stray.txt"}) {
+		t.Errorf("Expected stray.txt to be cleaned, got %v", plan.Clean)
+	}
+
+	// Рабочий каталог и текущий коммит не изменились
+	if gitRepo.CommitHash() != before || gitRepo.HasChanges() || gitRepo.LastUpdate() != nil {
+		t.Errorf("Expected no changes to be applied")
+	}
+	if data, _ := os.ReadFile(filepath.Join(localPath, "README.md")); string(data) != "local" {
+		t.Errorf("Expected local changes to be kept, got %q", data)
+	}
+	for name, expected := range map[string]bool{"stray.txt": true, "app.txt": false} {
+		if _, err := os.Stat(filepath.Join(localPath, name)); (err == nil) != expected {
+			t.Errorf("Expected %s to exist: %v", name, expected)
+		}
+	}
+}
+
+func TestDryRunControl(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add app", map[string]string{"app.txt": "app"})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagDryRun, false, "Dry run")
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagDryRun)
+
+	before := gitRepo.CommitHash()
+
+	// Операции управления не изменяют рабочий каталог и ссылки в режиме dry-run
+	if _, err := gitRepo.Pin(context.Background(), "", 1); !errors.Is(err, git.ErrDryRun) {
+		t.Errorf("Expected pin to be rejected, got %v", err)
+	}
+	if gitRepo.CommitHash() != before || gitRepo.Pinned() != nil {
+		t.Errorf("Expected no pin at %s, got %+v at %s", before, gitRepo.Pinned(), gitRepo.CommitHash())
+	}
+	if err := gitRepo.Unpin(); !errors.Is(err, git.ErrDryRun) {
+		t.Errorf("Expected unpin to be rejected, got %v", err)
+	}
+	if _, err := gitRepo.Approve(""); !errors.Is(err, git.ErrDryRun) {
+		t.Errorf("Expected approval to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// ErrInvalidOptions возвращается, если параметры репозитория заданы некорректно
+var ErrInvalidOptions = errors.New("invalid options")
+
+// IsAuthError проверяет, вызвана ли ошибка отказом в аутентификации или авторизации
+// на сервере удаленного репозитория
+func IsAuthError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) ||
+		errors.Is(err, transport.ErrInvalidAuthMethod) {
+		return true
+	}
+
+	// Ошибки SSH-рукопожатия go-git возвращает без обертки
+	message := err.Error()
+	return strings.Contains(message, "unable to authenticate") || strings.Contains(message, "knownhosts:")
+}
+
+// IsNetworkError проверяет, вызвана ли ошибка недоступностью сервера удаленного репозитория:
+// ошибкой соединения, разрешения имени или превышением времени clone, fetch и push
+func IsNetworkError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrCloneTimeout) || errors.Is(err, ErrFetchTimeout) || errors.Is(err, ErrPushTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"git-sync/mock"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// cloneError пытается клонировать репозиторий по адресу url и возвращает ошибку клонирования
+func cloneError(t *testing.T, url string) error {
+
+	mockFlags := mock.Flags()
+	mockFlags.String(constants.FlagRepoUrl, url, "URL of the repository")
+	mockFlags.String(constants.FlagLocalPath, filepath.Join(t.TempDir(), "repo"), "Local path for the repository")
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+
+	_, err := git.NewGitRepository(mockFlags, context.Background())
+	if err == nil {
+		t.Fatalf("Expected error cloning %s", url)
+	}
+
+	return err
+}
+
+func TestErrorClasses(t *testing.T) {
+
+	// Сервер отклоняет учетные данные
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := cloneError(t, server.URL+"/repo.git")
+	if !git.IsAuthError(err) || git.IsNetworkError(err) {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+
+	// Сервер недоступен
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	err = cloneError(t, "http://"+addr+"/repo.git")
+	if !git.IsNetworkError(err) || git.IsAuthError(err) {
+		t.Errorf("Expected network error, got %v", err)
+	}
+
+	// Отслеживаемая ссылка не задана
+	mockFlags := mock.Flags()
+	mockFlags.String(constants.FlagRepoUrl, server.URL, "URL of the repository")
+	mockFlags.String(constants.FlagLocalPath, t.TempDir(), "Local path for the repository")
+	if err := mockFlags.Parse([]string{"--" + constants.FlagRepoBranch + "="}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	_, err = git.NewGitRepository(mockFlags, context.Background())
+	if !errors.Is(err, git.ErrInvalidOptions) {
+		t.Errorf("Expected invalid options error, got %v", err)
+	}
+}
+
+func TestTimeoutErrorClasses(t *testing.T) {
+
+	// Превышение времени отправки отличается от превышения времени получения изменений
+	err := fmt.Errorf("%w after 1s: %w", git.ErrPushTimeout, context.DeadlineExceeded)
+	if !git.IsNetworkError(err) || errors.Is(err, git.ErrFetchTimeout) {
+		t.Errorf("Expected push timeout to be a network error, got %v", err)
+	}
+}
@@ -15,20 +15,24 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"git-sync/internal/constants"
+	"git-sync/internal/flags"
 	"git-sync/logger"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
 const (
@@ -41,24 +45,39 @@ type GitRepository struct {
 	repository    *git.Repository
 	currentCommit *CommitInfo
 	hasChanges    bool
+	depth         int         // Текущая глубина истории (0 - полная история)
+	currentTag    string      // Текущий тег (если отслеживается тег)
+	lastUpdate    *UpdateInfo // Обновление, найденное при последней синхронизации
+	lastPush      *PushInfo   // Отправка локальных изменений при последней синхронизации
+	pin           *PinInfo    // Закрепленный коммит (nil - следовать за отслеживаемой ссылкой)
+	dryRun        *DryRunInfo // Отчет последней синхронизации в режиме dry-run
+	held          *HoldInfo   // Ревизия, задержанная директивой в сообщении коммита
+
+	appToken gitHubAppToken // Кэшированный токен установки GitHub App
 }
 
 type ChangeInfo struct {
-	ChangeType string
-	FileName   string
-	FromHash   string
-	ToHash     string
+	ChangeType  string `json:"type"`
+	FileName    string `json:"path"`
+	OldFileName string `json:"old_path,omitempty"` // Прежний путь (для переименования)
+	FromHash    string `json:"from_hash,omitempty"`
+	ToHash      string `json:"to_hash,omitempty"`
+	Filter      string `json:"filter,omitempty"`  // Совпавший фильтр изменений (include:<шаблон> или exclude:<шаблон>)
+	Ignored     bool   `json:"ignored,omitempty"` // Изменение не учитывается фильтрами изменений
 }
 
 type CommitInfo struct {
-	Hash    string
-	Date    time.Time
-	Message string
-	Author  string
-	Email   string
-	Reason  string
-	commit  *object.Commit
-	Changes []ChangeInfo
+	Hash    string         `json:"hash"`
+	Date    time.Time      `json:"date"`
+	Message string         `json:"message"`
+	Author  string         `json:"author"`
+	Email   string         `json:"email"`
+	Reason  string         `json:"reason"`
+	Tag     string         `json:"tag,omitempty"`
+	commit  *object.Commit `json:"-"`
+	Changes []ChangeInfo   `json:"changes"`
+
+	Submodules []SubmoduleInfo `json:"submodules,omitempty"`
 }
 
 type GitRepositoryOptions struct {
@@ -68,6 +87,76 @@ type GitRepositoryOptions struct {
 	user       string // Имя пользователя (для аутентификации)
 	token      string // Токен (для аутентификации)
 	originName string // имя удаленного репозитория
+	authMode   string // Способ аутентификации (none, token, basic, ssh, github-app)
+	depth      int    // Глубина истории при клонировании и получении изменений (0 - полная история)
+	deepen     bool   // Углублять историю, если ее недостаточно для операции
+
+	userFile         string // Файл с именем пользователя (читается при каждой операции)
+	tokenFile        string // Файл с токеном (читается при каждой операции)
+	netrc            string // Файл .netrc с учетными данными
+	credentialHelper string // Программа git credential helper
+
+	gitHubAppID          string // Идентификатор приложения GitHub App
+	gitHubInstallationID string // Идентификатор установки приложения GitHub App
+	gitHubAppKeyFile     string // Файл закрытого ключа приложения GitHub App
+	gitHubAPIURL         string // Адрес GitHub API
+
+	tag               string             // Тег или шаблон тегов (вместо ветки)
+	tagConstraint     string             // Ограничение версий semver для выбора последнего тега
+	versionConstraint *versionConstraint // Разобранное ограничение версий
+	commit            string             // Хеш коммита (вместо ветки)
+
+	localChanges   string // Политика обработки локальных изменений (reset, backup, stash, refuse)
+	localBackupDir string // Каталог резервных копий локальных изменений
+
+	clean            bool     // Удалять неотслеживаемые файлы и каталоги
+	cleanKeepIgnored bool     // Не удалять игнорируемые файлы (.gitignore)
+	cleanProtected   []string // Шаблоны путей, которые не удаляются при очистке
+
+	ffOnly        bool   // Применять только обновления fast-forward
+	onFastForward string // Политика для обновлений fast-forward (follow, refuse, alert)
+	onRewrite     string // Политика для переписанной истории
+	onRollback    string // Политика для отката к предку текущего коммита
+
+	push            bool               // Фиксировать и отправлять локальные изменения вместо отмены
+	pushBranch      string             // Ветка для отправки (пустая - отслеживаемая ветка)
+	pushStrategy    string             // Объединение с удаленной веткой (rebase, merge)
+	pushConflict    string             // Действие при конфликте с удаленной веткой (fail, fallback)
+	pushAuthorName  string             // Имя автора коммита с локальными изменениями
+	pushAuthorEmail string             // Email автора коммита с локальными изменениями
+	pushMessage     *template.Template // Шаблон сообщения коммита с локальными изменениями
+
+	dryRun bool // Только сообщать о действиях синхронизации, не изменяя рабочий каталог
+
+	sparsePaths []string // Шаблоны путей частичного checkout (пустой список - все файлы)
+
+	changesInclude []string // Шаблоны путей, изменения которых учитываются (пустой список - все пути)
+	changesExclude []string // Шаблоны путей, изменения которых не учитываются
+
+	commitDirectives bool // Учитывать директивы [skip sync] и [hold] в сообщениях коммитов
+	submodules       bool // Рекурсивная инициализация и обновление подмодулей
+
+	publishLink string // Символическая ссылка на опубликованную ревизию (пустая - публикация отключена)
+	publishRoot string // Каталог для ревизий (по умолчанию .revisions рядом со ссылкой)
+	publishKeep int    // Количество хранимых предыдущих ревизий
+
+	cloneTimeout    time.Duration // Ограничение времени клонирования (0 - без ограничения)
+	fetchTimeout    time.Duration // Ограничение времени получения изменений
+	checkoutTimeout time.Duration // Ограничение времени переключения рабочего каталога
+
+	sshKey               string // Закрытый SSH-ключ (содержимое в формате PEM)
+	sshKeyFile           string // Путь к файлу закрытого SSH-ключа
+	sshKeyPassphrase     string // Пароль закрытого SSH-ключа
+	sshKeyPassphraseFile string // Файл с паролем закрытого SSH-ключа
+	sshKnownHosts        string // Путь к файлу known_hosts
+	sshStrictHostKey     bool   // Строгая проверка ключа SSH-сервера
+
+	caFile             string // Файл с дополнительными корневыми сертификатами (PEM)
+	clientCert         string // Файл клиентского сертификата для mTLS (PEM)
+	clientKey          string // Файл закрытого ключа клиентского сертификата (PEM)
+	insecureSkipVerify bool   // Не проверять сертификат сервера
+	proxy              string // Прокси-сервер (по умолчанию HTTPS_PROXY, HTTP_PROXY)
+	noProxy            string // Адреса без прокси-сервера (по умолчанию NO_PROXY)
 }
 
 // NewCommitInfo создает новый объект CommitInfo на основе git.Commit.
@@ -92,6 +181,8 @@ func NewGitRepositoryOptions(url, branch, path, user, token, originName string)
 		user:       user,
 		token:      token,
 		originName: originName,
+
+		sshStrictHostKey: true,
 	}
 }
 
@@ -115,22 +206,22 @@ func (ci *CommitInfo) AddChange(changeType, fileName, fromHash, toHash string) {
 }
 
 // NewGitRepository создает экземпляр GitRepository с значениями по умолчанию.
-func NewGitRepository(fs *flag.FlagSet) (*GitRepository, error) {
+func NewGitRepository(fs *flag.FlagSet, ctx context.Context) (*GitRepository, error) {
 
 	// Если flagSet не укзан, возвращаем ошибку
 	if fs == nil {
-		return nil, fmt.Errorf("FlagSet is nil")
+		return nil, fmt.Errorf("%w: FlagSet is nil", ErrInvalidOptions)
 	}
 
 	// Функция для получения значения флага или ошибки
 	getFlagValue := func(name string) (string, error) {
 		f := fs.Lookup(name)
 		if f == nil {
-			return "", fmt.Errorf("flag %s is not defined", name)
+			return "", fmt.Errorf("%w: flag %s is not defined", ErrInvalidOptions, name)
 		}
 		value := f.Value.(flag.Getter).Get().(string)
 		if value == "" {
-			return "", fmt.Errorf("flag %s is empty", name)
+			return "", fmt.Errorf("%w: flag %s is empty", ErrInvalidOptions, name)
 		}
 		return value, nil
 	}
@@ -141,14 +232,39 @@ func NewGitRepository(fs *flag.FlagSet) (*GitRepository, error) {
 		return nil, err
 	}
 
-	branch, err := getFlagValue(constants.FlagRepoBranch)
+	path, err := getFlagValue(constants.FlagLocalPath)
 	if err != nil {
 		return nil, err
 	}
 
-	path, err := getFlagValue(constants.FlagLocalPath)
+	// Отслеживаемая ссылка: ветка, тег (шаблон тегов, ограничение версий) или коммит
+	branch := flags.LookupValue(fs, constants.FlagRepoBranch, "")
+	tag := flags.LookupValue(fs, constants.FlagRepoTag, "")
+	tagConstraint := flags.LookupValue(fs, constants.FlagRepoTagConstraint, "")
+	commit := flags.LookupValue(fs, constants.FlagRepoCommit, "")
+
+	if branch == "" && tag == "" && tagConstraint == "" && commit == "" {
+		return nil, fmt.Errorf("%w: one of flags %s, %s, %s, %s must be set", ErrInvalidOptions,
+			constants.FlagRepoBranch, constants.FlagRepoTag, constants.FlagRepoTagConstraint, constants.FlagRepoCommit)
+	}
+
+	var constraint *versionConstraint
+	if tagConstraint != "" {
+		constraint, err = parseVersionConstraint(tagConstraint)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+		}
+	}
+
+	// Локальные изменения фиксируются поверх отслеживаемой ветки
+	push := flags.LookupValue(fs, constants.FlagPush, false)
+	if push && branch == "" {
+		return nil, fmt.Errorf("%w: flag %s requires %s", ErrInvalidOptions, constants.FlagPush, constants.FlagRepoBranch)
+	}
+
+	pushMessage, err := template.New(constants.FlagPushMessage).Parse(flags.LookupValue(fs, constants.FlagPushMessage, constants.PushMessage))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid push message template: %w", ErrInvalidOptions, err)
 	}
 
 	// Получение значений необязательных флагов
@@ -162,6 +278,76 @@ func NewGitRepository(fs *flag.FlagSet) (*GitRepository, error) {
 		user:       user,
 		token:      token,
 		originName: "origin",
+		authMode:   flags.LookupValue(fs, constants.FlagRepoAuthMode, constants.AuthModeAuto),
+		depth:      flags.LookupValue(fs, constants.FlagRepoDepth, 0),
+		deepen:     flags.LookupValue(fs, constants.FlagRepoDeepen, true),
+
+		userFile:         flags.LookupValue(fs, constants.FlagRepoAuthUserFile, ""),
+		tokenFile:        flags.LookupValue(fs, constants.FlagRepoAuthTokenFile, ""),
+		netrc:            flags.LookupValue(fs, constants.FlagRepoNetrc, ""),
+		credentialHelper: flags.LookupValue(fs, constants.FlagRepoCredentialHelper, ""),
+
+		gitHubAppID:          flags.LookupValue(fs, constants.FlagRepoGitHubAppID, ""),
+		gitHubInstallationID: flags.LookupValue(fs, constants.FlagRepoGitHubInstallationID, ""),
+		gitHubAppKeyFile:     flags.LookupValue(fs, constants.FlagRepoGitHubAppKeyFile, ""),
+		gitHubAPIURL:         flags.LookupValue(fs, constants.FlagRepoGitHubAPIURL, constants.GitHubAPIURL),
+
+		tag:               tag,
+		tagConstraint:     tagConstraint,
+		versionConstraint: constraint,
+		commit:            commit,
+
+		localChanges:   flags.LookupValue(fs, constants.FlagLocalChanges, constants.LocalChangesReset),
+		localBackupDir: flags.LookupValue(fs, constants.FlagLocalBackupDir, ""),
+
+		clean:            flags.LookupValue(fs, constants.FlagClean, false),
+		cleanKeepIgnored: flags.LookupValue(fs, constants.FlagCleanKeepIgnored, true),
+		cleanProtected:   flags.SplitList(flags.LookupValue(fs, constants.FlagCleanProtected, "")),
+
+		ffOnly:        flags.LookupValue(fs, constants.FlagFFOnly, false),
+		onFastForward: flags.LookupValue(fs, constants.FlagUpdateFastForward, constants.UpdateFollow),
+		onRewrite:     flags.LookupValue(fs, constants.FlagUpdateRewrite, constants.UpdateFollow),
+		onRollback:    flags.LookupValue(fs, constants.FlagUpdateRollback, constants.UpdateFollow),
+
+		push:            push,
+		pushBranch:      flags.LookupValue(fs, constants.FlagPushBranch, ""),
+		pushStrategy:    flags.LookupValue(fs, constants.FlagPushStrategy, constants.PushRebase),
+		pushConflict:    flags.LookupValue(fs, constants.FlagPushConflict, constants.PushConflictFail),
+		pushAuthorName:  flags.LookupValue(fs, constants.FlagPushAuthorName, constants.PushAuthorName),
+		pushAuthorEmail: flags.LookupValue(fs, constants.FlagPushAuthorEmail, constants.PushAuthorEmail),
+		pushMessage:     pushMessage,
+
+		dryRun: flags.LookupValue(fs, constants.FlagDryRun, false),
+
+		sparsePaths: flags.SplitList(flags.LookupValue(fs, constants.FlagSparsePaths, "")),
+
+		changesInclude: flags.SplitList(flags.LookupValue(fs, constants.FlagChangesInclude, "")),
+		changesExclude: flags.SplitList(flags.LookupValue(fs, constants.FlagChangesExclude, "")),
+
+		commitDirectives: flags.LookupValue(fs, constants.FlagCommitDirectives, true),
+		submodules:       flags.LookupValue(fs, constants.FlagSubmodules, false),
+
+		publishLink: flags.LookupValue(fs, constants.FlagPublishLink, ""),
+		publishRoot: flags.LookupValue(fs, constants.FlagPublishRoot, ""),
+		publishKeep: flags.LookupValue(fs, constants.FlagPublishKeep, constants.PublishKeep),
+
+		cloneTimeout:    flags.LookupValue(fs, constants.FlagCloneTimeout, time.Duration(0)),
+		fetchTimeout:    flags.LookupValue(fs, constants.FlagFetchTimeout, time.Duration(0)),
+		checkoutTimeout: flags.LookupValue(fs, constants.FlagCheckoutTimeout, time.Duration(0)),
+
+		sshKey:               flags.LookupValue(fs, constants.FlagRepoSSHKey, ""),
+		sshKeyFile:           flags.LookupValue(fs, constants.FlagRepoSSHKeyFile, ""),
+		sshKeyPassphrase:     flags.LookupValue(fs, constants.FlagRepoSSHKeyPassphrase, ""),
+		sshKeyPassphraseFile: flags.LookupValue(fs, constants.FlagRepoSSHKeyPassphraseFile, ""),
+		sshKnownHosts:        flags.LookupValue(fs, constants.FlagRepoSSHKnownHosts, ""),
+		sshStrictHostKey:     flags.LookupValue(fs, constants.FlagRepoSSHStrictHostKey, true),
+
+		caFile:             flags.LookupValue(fs, constants.FlagRepoCAFile, ""),
+		clientCert:         flags.LookupValue(fs, constants.FlagRepoClientCert, ""),
+		clientKey:          flags.LookupValue(fs, constants.FlagRepoClientKey, ""),
+		insecureSkipVerify: flags.LookupValue(fs, constants.FlagRepoInsecureSkipVerify, false),
+		proxy:              flags.LookupValue(fs, constants.FlagRepoProxy, ""),
+		noProxy:            flags.LookupValue(fs, constants.FlagRepoNoProxy, ""),
 	}
 
 	gitRepository := &GitRepository{
@@ -169,50 +355,113 @@ func NewGitRepository(fs *flag.FlagSet) (*GitRepository, error) {
 		options:       options,
 		repository:    nil,
 		currentCommit: nil,
+		depth:         options.depth,
+	}
+
+	// Настраиваем TLS и прокси-сервер для HTTP(S)
+	err = gitRepository.installTransport()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
 	}
 
 	// Получаем репозиторий
-	err = gitRepository.cloneOpenRepo()
+	err = gitRepository.cloneOpenRepo(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Восстанавливаем закрепление коммита
+	gitRepository.loadPin()
+
 	// Записываем текущий коммит
 	err = gitRepository.storeCurrentCommit("init")
 	if err != nil {
 		return nil, err
 	}
 
+	// Публикуем текущую ревизию
+	if options.dryRun {
+		logger.GetLogger().Warning("dry-run: the working tree is not changed, pending changes are only reported\n")
+	} else if err = gitRepository.publishRevision(ctx); err != nil {
+		return nil, err
+	}
+
 	return gitRepository, nil
 }
 
-// Sync выполняет синхронизацию локального и удаленного репозитория
-func (gitRepo *GitRepository) Sync() error {
+// Sync выполняет синхронизацию локального и удаленного репозитория.
+// Отмена контекста ctx прерывает сетевые операции и запись файлов.
+func (gitRepo *GitRepository) Sync(ctx context.Context) error {
 
 	var err error
 
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
 	gitRepo.resetChangesFlag()
+	gitRepo.resetLastUpdate()
+	gitRepo.resetLastPush()
+	gitRepo.resetDryRun()
 
 	// Открываем либо клонируем удаленный репозиторий
-	err = gitRepo.cloneOpenRepo() // тут не фиксируются изменения
+	err = gitRepo.cloneOpenRepo(ctx) // тут не фиксируются изменения
 	if err != nil {
 		return err
 	}
 
 	// Принимаем изменения из удаленного репозитория
-	err = gitRepo.fetchRepo()
+	err = gitRepo.fetchRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Закрепленный коммит не изменяется: локальные изменения не отправляются,
+	// отслеживаемая ссылка не применяется
+	pinned := gitRepo.Pinned() != nil
+
+	// В режиме dry-run изменения только определяются и записываются в отчет
+	if gitRepo.options.dryRun {
+		return gitRepo.syncDryRun(ctx, pinned)
+	}
+
+	// Фиксируем и отправляем локальные изменения в удаленный репозиторий.
+	// При конфликте синхронизация прерывается, локальные изменения сохраняются (fail),
+	// либо они обрабатываются политикой local-changes и применяется удаленная ветка (fallback).
+	if !pinned {
+		err = gitRepo.pushLocalChanges(ctx)
+		if errors.Is(err, ErrPushConflict) && gitRepo.options.pushConflict == constants.PushConflictFallback {
+			logger.GetLogger().Warning("%v, falling back to the remote branch (local changes: %s)\n", err, gitRepo.options.localChanges)
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Проверяем наличие изменений в структуре локального репозитория.
+	// Локальные изменения обрабатываются до получения удаленных, чтобы их можно было сохранить.
+	err = gitRepo.compareFiles(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Проверяем изменения между удаленным и локальным репозиториями
-	err = gitRepo.compareCommitTrees()
+	if !pinned {
+		err = gitRepo.compareCommitTrees(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Проверяем состояние подмодулей
+	err = gitRepo.compareSubmodules(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Проверяем наличие изменений в структуре локального репозитория
-	err = gitRepo.compareFiles()
+	// Публикуем новую ревизию
+	err = gitRepo.publishRevision(ctx)
 	if err != nil {
 		return err
 	}
@@ -250,8 +499,9 @@ func (gitRepo *GitRepository) CommitHash() string {
 	return gitRepo.currentCommit.Hash
 }
 
-// cloneRepo выполняет клонирование репозиторий
-func (gitRepo *GitRepository) cloneRepo() error {
+// cloneRepo выполняет клонирование репозиторий.
+// Время клонирования ограничено cloneTimeout, переключения на отслеживаемую ссылку - checkoutTimeout.
+func (gitRepo *GitRepository) cloneRepo(ctx context.Context) error {
 
 	repoDir := gitRepo.options.path
 	if gitRepo.options == nil {
@@ -276,17 +526,37 @@ func (gitRepo *GitRepository) cloneRepo() error {
 		return nil
 	}
 
-	repository, err := git.PlainClone(gitRepo.options.path, false, &git.CloneOptions{
-		URL: gitRepo.options.url, // URL удаленного репозитория
-		Auth: &http.TokenAuth{
-			Token: gitRepo.options.token, // Токен для аутентификации
-		},
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		return err
+	}
+
+	// При ошибке go-git удаляет частично клонированный репозиторий
+	err = withTimeout(ctx, gitRepo.options.cloneTimeout, ErrCloneTimeout, func(ctx context.Context) error {
+		repository, err := git.PlainCloneContext(ctx, gitRepo.options.path, false, &git.CloneOptions{
+			URL:        gitRepo.options.url, // URL удаленного репозитория
+			Auth:       auth,
+			Depth:      gitRepo.depth,      // Глубина истории (0 - полная история)
+			NoCheckout: gitRepo.isSparse(), // При частичном checkout файлы записываются отдельно
+			Tags:       gitRepo.options.tagMode(),
+
+			RecurseSubmodules: gitRepo.recurseSubmodules(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to clone repository: %w", err)
+		}
+		gitRepo.repository = repository
+		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to clone repository: %v", err)
+		return err
 	}
 
-	gitRepo.repository = repository
+	// Переключаемся на отслеживаемый тег или коммит и записываем файлы частичного checkout
+	err = withTimeout(ctx, gitRepo.options.checkoutTimeout, ErrCheckoutTimeout, gitRepo.checkoutInitial)
+	if err != nil {
+		return err
+	}
 
 	gitRepo.setChangesFlag(true)
 	gitRepo.storeCurrentCommit("local")
@@ -310,9 +580,9 @@ func (gitRepo *GitRepository) openRepo() error {
 }
 
 // cloneOpenRepo клонирует или открывает репозиторий
-func (gitRepo *GitRepository) cloneOpenRepo() error {
+func (gitRepo *GitRepository) cloneOpenRepo(ctx context.Context) error {
 
-	if err := gitRepo.cloneRepo(); err != nil {
+	if err := gitRepo.cloneRepo(ctx); err != nil {
 		return err
 	}
 
@@ -327,56 +597,38 @@ func (gitRepo *GitRepository) cloneOpenRepo() error {
 // используя принудительный fetch. Возвращает ошибку в случае
 // возникновения проблем при выполнении операции fetch. Если репозиторий
 // уже актуален и не требует обновления, возвращает nil без ошибки.
-func (gitRepo *GitRepository) fetchRepo() error {
+// Время выполнения ограничено fetchTimeout.
+func (gitRepo *GitRepository) fetchRepo(ctx context.Context) error {
 
 	remote, err := gitRepo.repository.Remote(gitRepo.options.originName)
 	if err != nil {
 		return fmt.Errorf("failed to get remote: %v", err)
 	}
 
-	// Выполняем fetch для получения обновлений из удаленного репозитория
-	err = remote.Fetch(&git.FetchOptions{
-		Auth: &http.TokenAuth{
-			Token: gitRepo.options.token,
-		},
-		Force: true,
-	})
-
-	if err == git.NoErrAlreadyUpToDate {
-		return nil // Репозиторий уже актуален, не возвращаем ошибку
-	}
-
-	if err != nil {
-		return fmt.Errorf("failed to fetch remote: %v", err)
-	}
-
-	return nil
-}
-
-// pullRepo выполняет операцию Pull для текущего репозитория с использованием указанных параметров.
-// Если force установлен в true, операция Pull будет выполнена с флагом Force для принудительного объединения изменений.
-// В случае ошибки при выполнении операции Pull, функция возвращает ошибку.
-func (gitRepo *GitRepository) pullRepo(force bool) error {
-
-	// Получаем объект Worktree из текущего репозитория
-	wt, err := gitRepo.getRepoWorktree()
+	auth, err := gitRepo.authMethod()
 	if err != nil {
 		return err
 	}
 
-	// Выполняем операцию Pull с указанными параметрами
-	err = wt.Pull(&git.PullOptions{
-		RemoteURL:  gitRepo.options.url,
-		RemoteName: gitRepo.options.originName,
-		Force:      force,
-	})
+	// Выполняем fetch для получения обновлений из удаленного репозитория
+	return withTimeout(ctx, gitRepo.options.fetchTimeout, ErrFetchTimeout, func(ctx context.Context) error {
+		err := remote.FetchContext(ctx, &git.FetchOptions{
+			Auth:  auth,
+			Depth: gitRepo.depth,
+			Tags:  gitRepo.options.tagMode(),
+			Force: true,
+		})
+
+		if err == git.NoErrAlreadyUpToDate {
+			return nil // Репозиторий уже актуален, не возвращаем ошибку
+		}
 
-	// Обрабатываем случаи ошибок
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to pull changes: %v", err)
-	}
+		if err != nil {
+			return fmt.Errorf("failed to fetch remote: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // resetRepo сбрасывает все изменения в локальном репозитории.
@@ -413,9 +665,9 @@ func (gitRepo *GitRepository) getRepoWorktree() (*git.Worktree, error) {
 }
 
 // storeCurrentCommit сохраняет информацию о текущем коммите внутри GitRepository.
-// Параметр "reason" представляет собой причину "сохранения" коммита.
+// Параметр "reason" представляет собой причину "сохранения" коммита, "changes" - изменения файлов.
 // Функция выполняет блокировку мьютекса GitRepository для безопасной работы с данными.
-func (gitRepo *GitRepository) storeCurrentCommit(reason string) error {
+func (gitRepo *GitRepository) storeCurrentCommit(reason string, changes ...ChangeInfo) error {
 
 	var err error
 
@@ -425,12 +677,21 @@ func (gitRepo *GitRepository) storeCurrentCommit(reason string) error {
 		return err
 	}
 
+	// Получаем текущие коммиты подмодулей
+	submodules, err := gitRepo.submodulesInfo()
+	if err != nil {
+		return err
+	}
+
 	// Блокируем мьютекс для безопасной работы с данными GitRepository
 	gitRepo.mutex.Lock()
 
 	// Создаем новый объект CommitInfo на основе текущего коммита
 	gitRepo.currentCommit = NewCommitInfo(commit)
 	gitRepo.currentCommit.Reason = reason
+	gitRepo.currentCommit.Submodules = submodules
+	gitRepo.currentCommit.Tag = gitRepo.currentTag
+	gitRepo.currentCommit.Changes = append(gitRepo.currentCommit.Changes, changes...)
 
 	// Снимаем блокировку мьютекса
 	gitRepo.mutex.Unlock()
@@ -476,7 +737,7 @@ func (gitRepo *GitRepository) getCommit(isRemote bool) (*object.Commit, error) {
 }
 
 // compareCommitTrees Проверяем наличие изменений между локальным и удаленным коммитами
-func (gitRepo *GitRepository) compareCommitTrees() error {
+func (gitRepo *GitRepository) compareCommitTrees(ctx context.Context) error {
 
 	// Получаем последний коммит локального репозитория
 	localCommit, err := gitRepo.getCommit(false)
@@ -484,52 +745,146 @@ func (gitRepo *GitRepository) compareCommitTrees() error {
 		return err
 	}
 
-	// Получаем последний коммит удаленного репозитория
-	remoteCommit, err := gitRepo.getCommit(true)
+	// Получаем последний коммит отслеживаемой ссылки удаленного репозитория
+	var (
+		remoteCommit *object.Commit
+		remoteTag    string
+	)
+	err = gitRepo.withHistory(ctx, func() error {
+		remoteCommit, remoteTag, err = gitRepo.getRemoteCommit()
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	// Получаем деревья для сравнения
-	localTree, err := localCommit.Tree()
-	if err != nil {
-		return fmt.Errorf("failed to get local tree: %v", err)
+	// Локальный репозиторий уже на коммите отслеживаемой ссылки
+	if localCommit.Hash == remoteCommit.Hash {
+		gitRepo.setHeld(nil)
+		if gitRepo.options.dryRun {
+			gitRepo.planRemoteChanges(remoteCommit.Hash.String(), nil)
+			return nil
+		}
+		gitRepo.currentTag = remoteTag
+
+		// Тег мог переместиться на текущий коммит: обновляем тег в информации о коммите
+		gitRepo.mutex.Lock()
+		if gitRepo.currentCommit != nil && gitRepo.currentCommit.Tag != remoteTag {
+			commit := *gitRepo.currentCommit
+			commit.Tag = remoteTag
+			gitRepo.currentCommit = &commit
+		}
+		gitRepo.mutex.Unlock()
+		return nil
+	}
+
+	// Ревизия не применяется, если сообщение коммита содержит директиву [skip sync] или [hold]
+	// и ревизия не подтверждена
+	held, err := gitRepo.holdRevision(ctx, localCommit, remoteCommit)
+	if err != nil || held {
+		return err
+	}
+
+	// Определяем вид обновления (fast-forward, переписывание истории, откат) и применяем политику.
+	// В режиме dry-run политика не применяется.
+	if gitRepo.options.dryRun {
+		err = gitRepo.planUpdate(ctx, localCommit, remoteCommit)
+	} else {
+		err = gitRepo.checkUpdate(ctx, localCommit, remoteCommit)
 	}
-	remoteTree, err := remoteCommit.Tree()
 	if err != nil {
-		return fmt.Errorf("failed to get remote tree: %v", err)
+		return err
 	}
 
-	// Сравниваем локальный и удаленный коммиты
-	diff, err := localTree.Diff(remoteTree)
+	var (
+		diff    object.Changes
+		changes []ChangeInfo
+	)
+
+	// Получаем деревья и сравниваем локальный и удаленный коммиты.
+	// При неполной истории объекты могут отсутствовать, тогда история углубляется.
+	err = gitRepo.withHistory(ctx, func() error {
+		localTree, err := localCommit.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get local tree: %w", err)
+		}
+		remoteTree, err := remoteCommit.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get remote tree: %w", err)
+		}
+		diff, err = localTree.Diff(remoteTree)
+		if err != nil {
+			return fmt.Errorf("failed to get diff: %w", err)
+		}
+		changes, err = gitRepo.changesInfo(diff)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get diff: %v", err)
+		return err
 	}
 
-	// Изменения найдены
-	if diff.Len() > 0 {
+	// Отмечаем изменения, не прошедшие фильтры изменений
+	relevant := gitRepo.filterChanges(changes)
 
-		gitRepo.setChangesFlag(true)
+	if gitRepo.options.dryRun {
+		gitRepo.planRemoteChanges(remoteCommit.Hash.String(), changes)
+		return nil
+	}
 
-		// принимаем изменения из удаленного репозитория (git pull --force)
-		err = gitRepo.pullRepo(true)
-		if err != nil {
+	// При частичном checkout учитываются только изменения в выбранных путях,
+	// рабочий каталог переключается и при изменениях, отфильтрованных фильтрами изменений
+	gitRepo.setChangesFlag(relevant > 0)
+
+	// переключаемся на удаленный коммит и обновляем подмодули до новых коммитов
+	err = withTimeout(ctx, gitRepo.options.checkoutTimeout, ErrCheckoutTimeout, func(ctx context.Context) error {
+		if err := gitRepo.checkoutRemote(ctx, remoteCommit, diff); err != nil {
 			return err
 		}
+		gitRepo.currentTag = remoteTag
+		_, err := gitRepo.syncSubmodules(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	gitRepo.storeCurrentCommit("remote", changes...)
+
+	if err := gitRepo.clearApprovals(); err != nil {
+		return err
+	}
+
+	return gitRepo.showCommitMessage()
+}
 
-		gitRepo.storeCurrentCommit("remote")
+// compareSubmodules Проверяем наличие изменений в подмодулях локального репозитория
+func (gitRepo *GitRepository) compareSubmodules(ctx context.Context) error {
 
+	var changed bool
+	err := withTimeout(ctx, gitRepo.options.checkoutTimeout, ErrCheckoutTimeout, func(ctx context.Context) error {
+		var err error
+		changed, err = gitRepo.syncSubmodules(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		gitRepo.storeCurrentCommit("submodule")
+
+		// выводим сообщение в лог
 		err = gitRepo.showCommitMessage()
 		if err != nil {
 			return err
 		}
-		return nil
 	}
+
 	return nil
 }
 
 // compareFiles Проверяем наличие изменений в файлах лольного репозитория
-func (gitRepo *GitRepository) compareFiles() error {
+func (gitRepo *GitRepository) compareFiles(ctx context.Context) error {
 
 	wt, err := gitRepo.getRepoWorktree()
 	if err != nil {
@@ -541,14 +896,46 @@ func (gitRepo *GitRepository) compareFiles() error {
 		return fmt.Errorf("failed to get status: %v", err)
 	}
 
-	if !status.IsClean() {
+	// В режиме dry-run локальные изменения и неотслеживаемые файлы только записываются в отчет
+	if gitRepo.options.dryRun {
+		var clean []string
+		if gitRepo.options.clean {
+			clean = gitRepo.cleanCandidates(status)
+		}
+		gitRepo.planLocalChanges(gitRepo.changedFiles(status), clean)
+		return nil
+	}
 
-		gitRepo.setChangesFlag(true)
+	// Удаляем неотслеживаемые файлы
+	if gitRepo.options.clean && (len(untrackedFiles(status)) > 0 || !gitRepo.options.cleanKeepIgnored) {
+		removed, err := gitRepo.cleanRepo(status)
+		if err != nil {
+			return err
+		}
+		if len(removed) > 0 {
+			// Изменения предыдущей синхронизации не должны учитываться повторно
+			gitRepo.setChangesFlag(true)
+			gitRepo.storeCurrentCommit("clean")
+		}
+	}
+
+	changedFiles := gitRepo.changedFiles(status)
 
-		// fmt.Println("Найдены изменения в локальном репозитории:")
-		// changedFiles := strings.Split(status.String(), "\n")
+	if len(changedFiles) > 0 {
 
-		err := gitRepo.resetRepo()
+		// Применяем политику обработки локальных изменений
+		err = gitRepo.handleLocalChanges(status, changedFiles)
+		if err != nil {
+			return err
+		}
+
+		gitRepo.setChangesFlag(true)
+
+		if gitRepo.isSparse() {
+			err = gitRepo.resetSparse(ctx, changedFiles)
+		} else {
+			err = gitRepo.resetRepo()
+		}
 		if err != nil {
 			return err
 		}
@@ -566,6 +953,30 @@ func (gitRepo *GitRepository) compareFiles() error {
 	return nil
 }
 
+// changedFiles возвращает отсортированный список измененных файлов локального репозитория.
+// Неотслеживаемые файлы не учитываются: сброс их не удаляет, они удаляются очисткой (cleanRepo).
+// При частичном checkout файлы вне выбранных путей не учитываются.
+func (gitRepo *GitRepository) changedFiles(status git.Status) []string {
+
+	var files []string
+	for name, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		if fileStatus.Worktree == git.Untracked {
+			continue
+		}
+		if !gitRepo.inSparse(name) {
+			continue
+		}
+		files = append(files, name)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
 // resetChangesFlag сбрасывает текущее значение флага "найдены изменения" в значение false
 func (gitRepo *GitRepository) resetChangesFlag() {
 	gitRepo.mutex.Lock()
@@ -606,5 +1017,22 @@ func (gitRepo *GitRepository) showCommitMessage() error {
 	// Вывод информации о коммите в лог
 	logger.GetLogger().Info("%s %s %s (%s) %s %s\n", reason, commitHash, authorName, authorEmail, commitDate, commitMessage)
 
+	// Вывод изменений файлов в лог
+	for _, change := range gitRepo.currentCommit.Changes {
+		ignored := ""
+		if change.Ignored {
+			ignored = " (ignored"
+			if change.Filter != "" {
+				ignored += " by " + change.Filter
+			}
+			ignored += ")"
+		}
+		if change.ChangeType == ChangeRename {
+			logger.GetLogger().Info("  %s %s -> %s%s\n", change.ChangeType, change.OldFileName, change.FileName, ignored)
+		} else {
+			logger.GetLogger().Info("  %s %s%s\n", change.ChangeType, change.FileName, ignored)
+		}
+	}
+
 	return nil
 }
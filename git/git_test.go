@@ -15,6 +15,7 @@
 package git_test
 
 import (
+	"context"
 	"git-sync/git"
 	"git-sync/internal/constants"
 	"git-sync/mock"
@@ -37,7 +38,7 @@ func TestNewGitRepositoryWithValidURL(t *testing.T) {
 	}
 
 	// Пытаемся создать новый GitRepository с правильным URL
-	gitRepo, err := git.NewGitRepository(mockFlags)
+	gitRepo, err := git.NewGitRepository(mockFlags, context.Background())
 	if err != nil {
 		t.Fatalf("Error initializing GitRepository: %v", err)
 	}
@@ -71,7 +72,7 @@ func TestNewGitRepositoryWithInvalidURL(t *testing.T) {
 	}
 
 	// Пытаемся создать новый GitRepository с неверным URL
-	_, err = git.NewGitRepository(mockFlags)
+	_, err = git.NewGitRepository(mockFlags, context.Background())
 	if err == nil {
 		t.Error("Expected error due to invalid repository URL, but got nil")
 	} else {
@@ -0,0 +1,196 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"git-sync/logger"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+const (
+	gitHubAppTokenUser    = "x-access-token" // Имя пользователя для токена установки
+	gitHubAppJWTLifetime  = 9 * time.Minute  // Срок действия JWT (GitHub допускает не более 10 минут)
+	gitHubAppClockSkew    = time.Minute      // Запас на расхождение часов с сервером
+	gitHubAppRefreshAhead = 5 * time.Minute  // Токен обновляется заранее, до истечения срока действия
+	gitHubAppTimeout      = 30 * time.Second // Ограничение времени запроса токена
+)
+
+// gitHubAppToken хранит полученный токен установки GitHub App и срок его действия
+type gitHubAppToken struct {
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// gitHubAppAuth возвращает метод аутентификации с токеном установки GitHub App.
+// Токен запрашивается заново, если до истечения его срока действия осталось меньше gitHubAppRefreshAhead.
+func (gitRepo *GitRepository) gitHubAppAuth() (transport.AuthMethod, error) {
+
+	appToken := &gitRepo.appToken
+
+	appToken.mutex.Lock()
+	defer appToken.mutex.Unlock()
+
+	if appToken.token == "" || time.Until(appToken.expiresAt) < gitHubAppRefreshAhead {
+		token, expiresAt, err := gitRepo.gitHubInstallationToken()
+		if err != nil {
+			return nil, err
+		}
+		appToken.token = token
+		appToken.expiresAt = expiresAt
+		logger.GetLogger().Info("GitHub App installation token refreshed, expires at %s\n", expiresAt.Format(dateFormat))
+	}
+
+	return &githttp.BasicAuth{
+		Username: gitHubAppTokenUser,
+		Password: appToken.token,
+	}, nil
+}
+
+// gitHubInstallationToken обменивает JWT приложения на токен установки
+// (POST /app/installations/{installation_id}/access_tokens).
+// Возвращает токен и срок его действия.
+func (gitRepo *GitRepository) gitHubInstallationToken() (string, time.Time, error) {
+
+	jwt, err := gitRepo.gitHubAppJWT(time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	endpoint := fmt.Sprintf("%s/app/installations/%s/access_tokens",
+		strings.TrimRight(gitRepo.options.gitHubAPIURL, "/"), gitRepo.options.gitHubInstallationID)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create GitHub App token request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	httpTransport, err := gitRepo.httpTransport()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	client := &http.Client{Transport: httpTransport, Timeout: gitHubAppTimeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to request GitHub App installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read GitHub App token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		// Отказ в выдаче токена означает ошибку аутентификации
+		err := transport.ErrAuthorizationFailed
+		if resp.StatusCode == http.StatusUnauthorized {
+			err = transport.ErrAuthenticationRequired
+		}
+		return "", time.Time{}, fmt.Errorf("GitHub App installation token request failed: %w: %s: %s",
+			err, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse GitHub App token response: %v", err)
+	}
+	if result.Token == "" {
+		return "", time.Time{}, errors.New("GitHub App token response does not contain a token")
+	}
+
+	return result.Token, result.ExpiresAt, nil
+}
+
+// gitHubAppJWT формирует JWT приложения GitHub App, подписанный закрытым ключом (RS256).
+// Ключ читается из файла при каждом вызове, что позволяет заменять его без перезапуска.
+func (gitRepo *GitRepository) gitHubAppJWT(now time.Time) (string, error) {
+
+	key, err := readRSAPrivateKey(gitRepo.options.gitHubAppKeyFile)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-gitHubAppClockSkew).Unix(),
+		"exp": now.Add(gitHubAppJWTLifetime).Unix(),
+		"iss": gitRepo.options.gitHubAppID,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %v", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// readRSAPrivateKey читает закрытый RSA-ключ в формате PEM (PKCS#1 или PKCS#8)
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in GitHub App private key %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+
+	return key, nil
+}
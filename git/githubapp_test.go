@@ -0,0 +1,165 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"git-sync/internal/constants"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// fakeGitHubAPI эмулирует выдачу токенов установки GitHub App.
+// Проверяет путь запроса и подпись JWT, выдает токены token-1, token-2, ...
+// со сроком действия lifetime.
+func fakeGitHubAPI(t *testing.T, key *rsa.PublicKey, lifetime time.Duration) (*httptest.Server, *int32) {
+
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed JWT", http.StatusUnauthorized)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if err := json.Unmarshal(payload, &claims); err != nil || claims.Iss != "1234" || claims.Exp <= claims.Iat {
+			http.Error(w, "bad claims", http.StatusUnauthorized)
+			return
+		}
+
+		n := atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"token":      fmt.Sprintf("token-%d", n),
+			"expires_at": time.Now().Add(lifetime).UTC().Format(time.RFC3339),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newGitHubAppRepo(t *testing.T, apiURL string, key *rsa.PrivateKey) *GitRepository {
+
+	dir := t.TempDir()
+
+	gitRepo := &GitRepository{
+		options: NewGitRepositoryOptions("https://github.com/owner/repo.git", "master", dir, "", "", "origin"),
+	}
+	gitRepo.options.authMode = constants.AuthModeGitHubApp
+	gitRepo.options.gitHubAppID = "1234"
+	gitRepo.options.gitHubInstallationID = "42"
+	gitRepo.options.gitHubAppKeyFile = writePEM(t, dir, "app.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	gitRepo.options.gitHubAPIURL = apiURL
+
+	return gitRepo
+}
+
+func gitHubAppPassword(t *testing.T, gitRepo *GitRepository) string {
+	t.Helper()
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%v'", err)
+	}
+	basicAuth, ok := auth.(*githttp.BasicAuth)
+	if !ok {
+		t.Fatalf("Expected *http.BasicAuth, got %T", auth)
+	}
+	if basicAuth.Username != gitHubAppTokenUser {
+		t.Errorf("Expected user %s, got %s", gitHubAppTokenUser, basicAuth.Username)
+	}
+	return basicAuth.Password
+}
+
+func TestGitHubAppAuth(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	server, requests := fakeGitHubAPI(t, &key.PublicKey, time.Hour)
+	gitRepo := newGitHubAppRepo(t, server.URL+"/", key)
+
+	if password := gitHubAppPassword(t, gitRepo); password != "token-1" {
+		t.Errorf("Expected token-1, got %s", password)
+	}
+
+	// Действующий токен берется из кэша
+	if password := gitHubAppPassword(t, gitRepo); password != "token-1" || *requests != 1 {
+		t.Errorf("Expected cached token-1 after 1 request, got %s after %d requests", password, *requests)
+	}
+
+	// Токен, срок действия которого скоро истекает, обновляется
+	gitRepo.appToken.expiresAt = time.Now().Add(time.Minute)
+	if password := gitHubAppPassword(t, gitRepo); password != "token-2" {
+		t.Errorf("Expected refreshed token-2, got %s", password)
+	}
+}
+
+func TestGitHubAppAuthRejected(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	// Сервер ожидает подпись другим ключом
+	server, _ := fakeGitHubAPI(t, &other.PublicKey, time.Hour)
+	gitRepo := newGitHubAppRepo(t, server.URL, key)
+
+	_, err = gitRepo.authMethod()
+	if !errors.Is(err, transport.ErrAuthenticationRequired) || !IsAuthError(err) {
+		t.Errorf("Expected authentication error, got '%v'", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"flag"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"git-sync/mock"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// remoteRepo - локальный репозиторий, который используется в тестах в качестве удаленного
+type remoteRepo struct {
+	t          *testing.T
+	path       string
+	repository *gogit.Repository
+}
+
+// newRemoteRepo создает репозиторий с веткой master и одним коммитом
+func newRemoteRepo(t *testing.T) *remoteRepo {
+
+	path := t.TempDir()
+
+	repository, err := gogit.PlainInitWithOptions(path, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.Master},
+	})
+	if err != nil {
+		t.Fatalf("Error initializing remote repository: %v", err)
+	}
+
+	remote := &remoteRepo{t: t, path: path, repository: repository}
+	remote.commit("initial commit", map[string]string{"README.md": "readme"})
+
+	return remote
+}
+
+// url возвращает адрес удаленного репозитория
+func (r *remoteRepo) url() string {
+	return "file://" + r.path
+}
+
+// commit записывает файлы и создает коммит. Пустое содержимое означает удаление файла.
+func (r *remoteRepo) commit(message string, files map[string]string) plumbing.Hash {
+
+	wt, err := r.repository.Worktree()
+	if err != nil {
+		r.t.Fatalf("Error getting remote worktree: %v", err)
+	}
+
+	for name, content := range files {
+		fullPath := filepath.Join(r.path, name)
+		if content == "" {
+			if _, err := wt.Remove(name); err != nil {
+				r.t.Fatalf("Error removing file %s: %v", name, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			r.t.Fatalf("Error creating directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			r.t.Fatalf("Error writing file %s: %v", name, err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			r.t.Fatalf("Error adding file %s: %v", name, err)
+		}
+	}
+
+	hash, err := wt.Commit(message, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatalf("Error creating commit: %v", err)
+	}
+
+	return hash
+}
+
+// newTestFlags создает набор флагов для клонирования удаленного репозитория в localPath
+func newTestFlags(t *testing.T, remote *remoteRepo, localPath string) *flag.FlagSet {
+
+	mockFlags := mock.Flags()
+	mockFlags.String(constants.FlagRepoUrl, remote.url(), "URL of the repository")
+	mockFlags.String(constants.FlagLocalPath, localPath, "Local path for the repository")
+	mockFlags.String(constants.FlagRepoAuthMode, constants.AuthModeNone, "Repository authentication mode")
+
+	return mockFlags
+}
+
+// newTestRepository создает GitRepository для удаленного репозитория с указанными флагами
+func newTestRepository(t *testing.T, fs *flag.FlagSet, args ...string) *git.GitRepository {
+
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+
+	gitRepo, err := git.NewGitRepository(fs, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitRepository: %v", err)
+	}
+
+	return gitRepo
+}
+
+// commitSubmodule записывает в репозиторий подмодуль path, указывающий на коммит hash репозитория sub
+func (r *remoteRepo) commitSubmodule(message, path string, sub *remoteRepo, hash plumbing.Hash) plumbing.Hash {
+
+	gitmodules := "[submodule \"" + path + "\"]\n\tpath = " + path + "\n\turl = " + sub.url() + "\n"
+	if err := os.WriteFile(filepath.Join(r.path, ".gitmodules"), []byte(gitmodules), 0644); err != nil {
+		r.t.Fatalf("Error writing .gitmodules: %v", err)
+	}
+
+	wt, err := r.repository.Worktree()
+	if err != nil {
+		r.t.Fatalf("Error getting remote worktree: %v", err)
+	}
+	if _, err := wt.Add(".gitmodules"); err != nil {
+		r.t.Fatalf("Error adding .gitmodules: %v", err)
+	}
+
+	// Записываем в индекс ссылку на коммит подмодуля
+	idx, err := r.repository.Storer.Index()
+	if err != nil {
+		r.t.Fatalf("Error reading index: %v", err)
+	}
+	if entry, err := idx.Entry(path); err == nil {
+		entry.Hash = hash
+	} else {
+		entry := idx.Add(path)
+		entry.Mode = filemode.Submodule
+		entry.Hash = hash
+	}
+	if err := r.repository.Storer.SetIndex(idx); err != nil {
+		r.t.Fatalf("Error writing index: %v", err)
+	}
+
+	commit, err := wt.Commit(message, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatalf("Error creating commit: %v", err)
+	}
+
+	return commit
+}
+
+// resetTo переключает ветку master удаленного репозитория на коммит hash (аналог force-push)
+func (r *remoteRepo) resetTo(hash plumbing.Hash) {
+
+	wt, err := r.repository.Worktree()
+	if err != nil {
+		r.t.Fatalf("Error getting remote worktree: %v", err)
+	}
+
+	if err := wt.Reset(&gogit.ResetOptions{Commit: hash, Mode: gogit.HardReset}); err != nil {
+		r.t.Fatalf("Error resetting remote repository: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"git-sync/internal/constants"
+	"git-sync/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrLocalChanges возвращается, если в локальном репозитории есть изменения,
+// а политика обработки локальных изменений запрещает их отменять.
+var ErrLocalChanges = errors.New("local changes found")
+
+// stashRefPrefix - префикс ссылок, в которых сохраняются локальные изменения
+const stashRefPrefix = "refs/git-sync/stash/"
+
+// localTimestampFormat - формат метки времени для каталогов резервных копий и ссылок
+const localTimestampFormat = "20060102-150405"
+
+// backupDir возвращает каталог для резервных копий локальных изменений
+func (gitRepo *GitRepository) backupDir() string {
+	if gitRepo.options.localBackupDir != "" {
+		return gitRepo.options.localBackupDir
+	}
+	path := filepath.Clean(gitRepo.options.path)
+	return filepath.Join(filepath.Dir(path), filepath.Base(path)+".backup")
+}
+
+// handleLocalChanges применяет политику обработки локальных изменений перед их отменой:
+// выводит список отменяемых файлов, сохраняет их резервную копию или ссылку с изменениями,
+// либо возвращает ErrLocalChanges, если отмена изменений запрещена.
+func (gitRepo *GitRepository) handleLocalChanges(status git.Status, files []string) error {
+
+	policy := gitRepo.options.localChanges
+
+	if policy == constants.LocalChangesRefuse {
+		return fmt.Errorf("%w: %s", ErrLocalChanges, strings.Join(files, ", "))
+	}
+
+	for _, name := range files {
+		fileStatus := status.File(name)
+		logger.GetLogger().Warning("local %c%c %s\n", fileStatus.Staging, fileStatus.Worktree, name)
+	}
+
+	switch policy {
+	case constants.LocalChangesBackup:
+		return gitRepo.backupLocalChanges(files)
+	case constants.LocalChangesStash:
+		return gitRepo.stashLocalChanges(files)
+	}
+
+	return nil
+}
+
+// backupLocalChanges копирует измененные файлы в каталог с меткой времени.
+// Удаленные файлы не копируются.
+func (gitRepo *GitRepository) backupLocalChanges(files []string) error {
+
+	dir := filepath.Join(gitRepo.backupDir(), time.Now().Format(localTimestampFormat))
+
+	copied := 0
+	for _, name := range files {
+
+		src := filepath.Join(gitRepo.options.path, filepath.FromSlash(name))
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+
+		err := copyFile(src, dst)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to backup %s: %v", name, err)
+		}
+		copied++
+	}
+
+	if copied > 0 {
+		logger.GetLogger().Info("local changes saved to %s\n", dir)
+	}
+
+	return nil
+}
+
+// copyFile копирует файл src в dst с сохранением режима доступа.
+// Символические ссылки копируются как ссылки.
+func copyFile(src, dst string) error {
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// stashLocalChanges сохраняет измененные файлы в коммит поверх текущего и записывает его
+// в ссылку refs/git-sync/stash/<метка времени>. HEAD и индекс возвращаются к исходному коммиту,
+// рабочий каталог не изменяется.
+func (gitRepo *GitRepository) stashLocalChanges(files []string) error {
+
+	head, err := gitRepo.repository.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %v", err)
+	}
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		if _, err := wt.Add(name); err != nil {
+			return fmt.Errorf("failed to stash %s: %v", name, err)
+		}
+	}
+
+	now := time.Now()
+	signature := &object.Signature{Name: "git-sync", Email: "git-sync@localhost", When: now}
+
+	hash, err := wt.Commit("git-sync: local changes "+now.Format(time.RFC3339), &git.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stash local changes: %v", err)
+	}
+
+	// Возвращаем HEAD и индекс к исходному коммиту
+	err = wt.Reset(&git.ResetOptions{
+		Commit: head.Hash(),
+		Mode:   git.MixedReset,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset index: %v", err)
+	}
+
+	ref := plumbing.NewHashReference(plumbing.ReferenceName(stashRefPrefix+now.Format(localTimestampFormat)), hash)
+	if err := gitRepo.repository.Storer.SetReference(ref); err != nil {
+		return fmt.Errorf("failed to store stash reference: %v", err)
+	}
+
+	logger.GetLogger().Info("local changes stashed to %s %s\n", ref.Name(), hash)
+
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// newLocalChangesRepository клонирует удаленный репозиторий с указанной политикой
+// обработки локальных изменений и изменяет файл app.conf в рабочем каталоге.
+func newLocalChangesRepository(t *testing.T, remote *remoteRepo, policy string, args ...string) (*git.GitRepository, string) {
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.String(constants.FlagLocalChanges, constants.LocalChangesReset, "Local changes policy")
+	mockFlags.String(constants.FlagLocalBackupDir, "", "Local backup dir")
+
+	gitRepo := newTestRepository(t, mockFlags, append(args, "--"+constants.FlagLocalChanges+"="+policy)...)
+
+	if err := os.WriteFile(filepath.Join(localPath, "app.conf"), []byte("hotfix"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return gitRepo, localPath
+}
+
+func TestLocalChangesPolicy(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add config", map[string]string{"app.conf": "original"})
+
+	readConf := func(localPath string) string {
+		content, _ := os.ReadFile(filepath.Join(localPath, "app.conf"))
+		return string(content)
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesReset)
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		if readConf(localPath) != "original" {
+			t.Errorf("Expected local changes to be reset, got %q", readConf(localPath))
+		}
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		backupDir := t.TempDir()
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesBackup,
+			"--"+constants.FlagLocalBackupDir+"="+backupDir)
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		if readConf(localPath) != "original" {
+			t.Errorf("Expected local changes to be reset, got %q", readConf(localPath))
+		}
+
+		backups, _ := filepath.Glob(filepath.Join(backupDir, "*", "app.conf"))
+		if len(backups) != 1 {
+			t.Fatalf("Expected one backup of app.conf, got %v", backups)
+		}
+		if content, _ := os.ReadFile(backups[0]); string(content) != "hotfix" {
+			t.Errorf("Expected backup with local changes, got %q", content)
+		}
+	})
+
+	t.Run("stash", func(t *testing.T) {
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesStash)
+		head := gitRepo.CommitHash()
+		if err := gitRepo.Sync(context.Background()); err != nil {
+			t.Fatalf("Error syncing repository: %v", err)
+		}
+		if readConf(localPath) != "original" || gitRepo.CommitHash() != head {
+			t.Errorf("Expected local changes to be reset on %s, got %q on %s", head, readConf(localPath), gitRepo.CommitHash())
+		}
+
+		repository, err := gogit.PlainOpen(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs, err := repository.References()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stash *plumbing.Reference
+		refs.ForEach(func(ref *plumbing.Reference) error {
+			if strings.HasPrefix(ref.Name().String(), "refs/git-sync/stash/") {
+				stash = ref
+			}
+			return nil
+		})
+		if stash == nil {
+			t.Fatal("Expected a stash reference")
+		}
+
+		commit, err := repository.CommitObject(stash.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commit.NumParents() != 1 || commit.ParentHashes[0].String() != head {
+			t.Errorf("Expected stash commit on top of %s", head)
+		}
+		file, err := commit.File("app.conf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content, _ := file.Contents(); content != "hotfix" {
+			t.Errorf("Expected stashed local changes, got %q", content)
+		}
+	})
+
+	t.Run("refuse", func(t *testing.T) {
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesRefuse)
+		head := gitRepo.CommitHash()
+		remote.commit("update readme", map[string]string{"README.md": "updated"})
+
+		err := gitRepo.Sync(context.Background())
+		if !errors.Is(err, git.ErrLocalChanges) {
+			t.Fatalf("Expected ErrLocalChanges, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "app.conf") {
+			t.Errorf("Expected error to list app.conf, got %v", err)
+		}
+		if readConf(localPath) != "hotfix" || gitRepo.CommitHash() != head {
+			t.Errorf("Expected local changes and commit to be kept, got %q on %s", readConf(localPath), gitRepo.CommitHash())
+		}
+	})
+}
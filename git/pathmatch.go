@@ -0,0 +1,74 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"path"
+	"strings"
+)
+
+// matchPath проверяет, соответствует ли путь name шаблону pattern.
+// Шаблон разбивается на сегменты по "/", каждый сегмент сравнивается по правилам path.Match,
+// сегмент "**" соответствует любому количеству каталогов.
+// Шаблон, совпавший с началом пути, соответствует всему содержимому каталога
+// (например, "deploy/prod" соответствует "deploy/prod/app.yaml").
+func matchPath(pattern, name string) bool {
+
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(strings.Trim(name, "/"), "/"))
+}
+
+// matchSegments сравнивает сегменты шаблона с сегментами пути
+func matchSegments(pattern, name []string) bool {
+
+	for len(pattern) > 0 {
+
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return true
+}
+
+// matchAnyPath возвращает первый шаблон из patterns, которому соответствует путь name
+func matchAnyPath(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchPath(pattern, name) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"deploy/prod", "deploy/prod/app.yaml", true},
+		{"deploy/prod/", "deploy/prod/nested/app.yaml", true},
+		{"deploy/prod", "deploy/production/app.yaml", false},
+		{"deploy/*", "deploy/stage/app.yaml", true},
+		{"deploy/*/app.yaml", "deploy/stage/app.yaml", true},
+		{"deploy/*/app.yaml", "deploy/stage/db.yaml", false},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"**/*.md", "docs/README.md", true},
+		{"**/*.md", "README.md", true},
+		{"docs/**", "docs/a/b/c.txt", true},
+		{"", "README.md", false},
+		{"[", "README.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if result := matchPath(tt.pattern, tt.name); result != tt.expected {
+				t.Errorf("matchPath(%q, %q): expected %t, got %t", tt.pattern, tt.name, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestMatchAnyPath(t *testing.T) {
+
+	patterns := []string{"docs", "**/*.yaml"}
+
+	if pattern, ok := matchAnyPath(patterns, "deploy/app.yaml"); !ok || pattern != "**/*.yaml" {
+		t.Errorf("Expected pattern '**/*.yaml', got '%s' (%t)", pattern, ok)
+	}
+
+	if _, ok := matchAnyPath(patterns, "main.go"); ok {
+		t.Error("Expected no match for 'main.go'")
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/logger"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// pinRefName - ссылка на закрепленный коммит. Сохраняется в локальном репозитории,
+// поэтому закрепление действует и после перезапуска.
+const pinRefName = "refs/git-sync/pin"
+
+// pinPreviousRefName - ссылка на коммит до закрепления, сохраняется вместе с pinRefName
+const pinPreviousRefName = "refs/git-sync/pin-previous"
+
+// ErrInvalidPin возвращается, если коммит для закрепления не задан или не найден
+var ErrInvalidPin = errors.New("invalid pin")
+
+// PinInfo содержит информацию о закрепленном коммите
+type PinInfo struct {
+	Commit   string    `json:"commit"`             // Закрепленный коммит
+	Previous string    `json:"previous,omitempty"` // Коммит до закрепления
+	Time     time.Time `json:"time"`
+}
+
+// Pin переключает рабочий каталог на коммит commit (полный или сокращенный хеш) либо на коммит,
+// отстоящий от текущего на back ревизий по первому родителю, и прекращает следование за
+// отслеживаемой ссылкой до вызова Unpin. Не должен вызываться одновременно с Sync.
+func (gitRepo *GitRepository) Pin(ctx context.Context, commit string, back int) (*PinInfo, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if gitRepo.options.dryRun {
+		return nil, fmt.Errorf("pin: %w", ErrDryRun)
+	}
+
+	if commit == "" && back <= 0 {
+		return nil, fmt.Errorf("%w: commit or number of revisions back must be set", ErrInvalidPin)
+	}
+
+	current, err := gitRepo.getCommit(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		target  *object.Commit
+		diff    object.Changes
+		changes []ChangeInfo
+	)
+
+	// Ищем коммит и изменения относительно текущего, при неполной истории она углубляется
+	err = gitRepo.withHistory(ctx, func() error {
+		target, err = gitRepo.resolvePin(current, commit, back)
+		if err != nil {
+			return err
+		}
+		currentTree, err := current.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get local tree: %w", err)
+		}
+		targetTree, err := target.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get pinned tree: %w", err)
+		}
+		diff, err = currentTree.Diff(targetTree)
+		if err != nil {
+			return fmt.Errorf("failed to get diff: %w", err)
+		}
+		changes, err = gitRepo.changesInfo(diff)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Hash != current.Hash {
+		// Переключение сбрасывает рабочий каталог, поэтому локальные изменения обрабатываются заранее
+		if err := gitRepo.pinLocalChanges(); err != nil {
+			return nil, err
+		}
+		err = withTimeout(ctx, gitRepo.options.checkoutTimeout, ErrCheckoutTimeout, func(ctx context.Context) error {
+			if err := gitRepo.checkoutRemote(ctx, target, diff); err != nil {
+				return err
+			}
+			_, err := gitRepo.syncSubmodules(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := gitRepo.repository.Storer.SetReference(plumbing.NewHashReference(pinPreviousRefName, current.Hash)); err != nil {
+		return nil, fmt.Errorf("failed to store pin reference: %v", err)
+	}
+	if err := gitRepo.repository.Storer.SetReference(plumbing.NewHashReference(pinRefName, target.Hash)); err != nil {
+		return nil, fmt.Errorf("failed to store pin reference: %v", err)
+	}
+
+	pin := &PinInfo{
+		Commit:   target.Hash.String(),
+		Previous: current.Hash.String(),
+		Time:     time.Now(),
+	}
+
+	gitRepo.mutex.Lock()
+	gitRepo.pin = pin
+	gitRepo.mutex.Unlock()
+
+	logger.GetLogger().Warning("pinned at %s, the tracked reference is not followed until unpinned\n", pin.Commit)
+
+	gitRepo.storeCurrentCommit("pin", changes...)
+
+	if err := gitRepo.showCommitMessage(); err != nil {
+		return pin, err
+	}
+
+	return pin, gitRepo.publishRevision(ctx)
+}
+
+// pinLocalChanges применяет политику обработки локальных изменений перед закреплением коммита.
+// В режиме отправки локальных изменений (--push) закрепление отклоняется: изменения не отправляются
+// при закрепленном коммите и были бы потеряны.
+func (gitRepo *GitRepository) pinLocalChanges() error {
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %v", err)
+	}
+
+	changedFiles := gitRepo.changedFiles(status)
+	if len(changedFiles) == 0 {
+		return nil
+	}
+
+	if gitRepo.options.push {
+		return fmt.Errorf("%w: push them before pinning: %s", ErrLocalChanges, strings.Join(changedFiles, ", "))
+	}
+
+	if err := gitRepo.handleLocalChanges(status, changedFiles); err != nil {
+		return err
+	}
+
+	gitRepo.setChangesFlag(true)
+	return nil
+}
+
+// resolvePin возвращает коммит для закрепления: по хешу commit или на back ревизий раньше текущего
+func (gitRepo *GitRepository) resolvePin(current *object.Commit, commit string, back int) (*object.Commit, error) {
+
+	if commit != "" {
+		hash, err := gitRepo.repository.ResolveRevision(plumbing.Revision(commit))
+		if err != nil {
+			return nil, fmt.Errorf("%w: commit %s: %w", ErrInvalidPin, commit, err)
+		}
+		target, err := gitRepo.repository.CommitObject(*hash)
+		if err != nil {
+			return nil, fmt.Errorf("%w: commit %s: %w", ErrInvalidPin, commit, err)
+		}
+		return target, nil
+	}
+
+	target := current
+	for i := 0; i < back; i++ {
+		parent, err := target.Parent(0)
+		if errors.Is(err, object.ErrParentNotFound) {
+			return nil, fmt.Errorf("%w: history of %s has only %d revisions before it", ErrInvalidPin, current.Hash, i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of %s: %w", target.Hash, err)
+		}
+		target = parent
+	}
+
+	return target, nil
+}
+
+// Unpin снимает закрепление. Следующая синхронизация вернет рабочий каталог к отслеживаемой ссылке.
+func (gitRepo *GitRepository) Unpin() error {
+
+	if gitRepo.options.dryRun {
+		return fmt.Errorf("unpin: %w", ErrDryRun)
+	}
+
+	for _, name := range []plumbing.ReferenceName{pinRefName, pinPreviousRefName} {
+		if err := gitRepo.repository.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("failed to remove pin reference: %v", err)
+		}
+	}
+
+	gitRepo.mutex.Lock()
+	pin := gitRepo.pin
+	gitRepo.pin = nil
+	gitRepo.mutex.Unlock()
+
+	if pin != nil && pin.Previous != "" {
+		logger.GetLogger().Info("unpinned from %s (pinned over %s)\n", pin.Commit, pin.Previous)
+	} else if pin != nil {
+		logger.GetLogger().Info("unpinned from %s\n", pin.Commit)
+	}
+
+	return nil
+}
+
+// Pinned возвращает информацию о закрепленном коммите или nil, если закрепления нет
+func (gitRepo *GitRepository) Pinned() *PinInfo {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	return gitRepo.pin
+}
+
+// loadPin восстанавливает закрепление из ссылки в локальном репозитории (после перезапуска)
+func (gitRepo *GitRepository) loadPin() {
+
+	ref, err := gitRepo.repository.Reference(pinRefName, false)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to load pin reference: %v\n", err)
+		return
+	}
+
+	pin := &PinInfo{Commit: ref.Hash().String(), Time: time.Now()}
+
+	previous, err := gitRepo.repository.Reference(pinPreviousRefName, false)
+	switch {
+	case err == nil:
+		pin.Previous = previous.Hash().String()
+	case !errors.Is(err, plumbing.ErrReferenceNotFound):
+		logger.GetLogger().Error("failed to load pin reference: %v\n", err)
+	}
+
+	gitRepo.mutex.Lock()
+	gitRepo.pin = pin
+	gitRepo.mutex.Unlock()
+
+	logger.GetLogger().Warning("pinned at %s, the tracked reference is not followed until unpinned\n", ref.Hash())
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPin(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	first := remote.commit("first", map[string]string{"app.txt": "first"})
+	second := remote.commit("second", map[string]string{"app.txt": "second"})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	gitRepo := newTestRepository(t, newTestFlags(t, remote, localPath))
+
+	readApp := func() string {
+		content, _ := os.ReadFile(filepath.Join(localPath, "app.txt"))
+		return string(content)
+	}
+
+	// Закрепление на одну ревизию назад
+	pin, err := gitRepo.Pin(context.Background(), "", 1)
+	if err != nil {
+		t.Fatalf("Error pinning: %v", err)
+	}
+	if pin.Commit != first.String() || pin.Previous != second.String() {
+		t.Errorf("Unexpected pin: %+v", pin)
+	}
+	if readApp() != "first" || gitRepo.CommitHash() != first.String() {
+		t.Errorf("Expected pinned commit in worktree, got %q at %s", readApp(), gitRepo.CommitHash())
+	}
+
+	// Синхронизация не следует за веткой, пока коммит закреплен
+	remote.commit("third", map[string]string{"app.txt": "third"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if readApp() != "first" || gitRepo.CommitHash() != first.String() {
+		t.Errorf("Expected pinned commit after sync, got %q at %s", readApp(), gitRepo.CommitHash())
+	}
+
+	// Закрепление сохраняется после перезапуска
+	restarted := newTestRepository(t, newTestFlags(t, remote, localPath))
+	if pin := restarted.Pinned(); pin == nil || pin.Commit != first.String() || pin.Previous != second.String() {
+		t.Errorf("Expected pin to be restored, got %+v", pin)
+	}
+
+	// Закрепление по сокращенному хешу
+	if _, err := gitRepo.Pin(context.Background(), second.String()[:7], 0); err != nil {
+		t.Fatalf("Error pinning by hash: %v", err)
+	}
+	if readApp() != "second" {
+		t.Errorf("Expected second commit in worktree, got %q", readApp())
+	}
+
+	// После снятия закрепления синхронизация возвращается к ветке
+	if err := gitRepo.Unpin(); err != nil {
+		t.Fatalf("Error unpinning: %v", err)
+	}
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.Pinned() != nil || readApp() != "third" {
+		t.Errorf("Expected branch to be followed after unpin, got %q", readApp())
+	}
+}
+
+func TestPinInvalid(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	localPath := filepath.Join(t.TempDir(), "repo")
+	gitRepo := newTestRepository(t, newTestFlags(t, remote, localPath))
+
+	tests := []struct {
+		name   string
+		commit string
+		back   int
+	}{
+		{"empty", "", 0},
+		{"unknown commit", "0123456789abcdef0123456789abcdef01234567", 0},
+		{"too far back", "", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gitRepo.Pin(context.Background(), tt.commit, tt.back); !errors.Is(err, git.ErrInvalidPin) {
+				t.Errorf("Expected ErrInvalidPin, got %v", err)
+			}
+			if gitRepo.Pinned() != nil {
+				t.Errorf("Expected no pin after error")
+			}
+		})
+	}
+}
+
+func TestPinLocalChanges(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add config", map[string]string{"app.conf": "original"})
+	remote.commit("update config", map[string]string{"app.conf": "updated"})
+
+	readConf := func(localPath string) string {
+		content, _ := os.ReadFile(filepath.Join(localPath, "app.conf"))
+		return string(content)
+	}
+
+	// Закрепление не уничтожает локальные изменения, если политика запрещает их отменять
+	t.Run("refuse", func(t *testing.T) {
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesRefuse)
+		head := gitRepo.CommitHash()
+		if _, err := gitRepo.Pin(context.Background(), "", 1); !errors.Is(err, git.ErrLocalChanges) {
+			t.Errorf("Expected ErrLocalChanges, got %v", err)
+		}
+		if readConf(localPath) != "hotfix" || gitRepo.CommitHash() != head || gitRepo.Pinned() != nil {
+			t.Errorf("Expected local changes to be kept on %s, got %q on %s", head, readConf(localPath), gitRepo.CommitHash())
+		}
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		backupDir := t.TempDir()
+		gitRepo, localPath := newLocalChangesRepository(t, remote, constants.LocalChangesBackup,
+			"--"+constants.FlagLocalBackupDir+"="+backupDir)
+		if _, err := gitRepo.Pin(context.Background(), "", 1); err != nil {
+			t.Fatalf("Error pinning: %v", err)
+		}
+		if readConf(localPath) != "original" {
+			t.Errorf("Expected pinned commit in worktree, got %q", readConf(localPath))
+		}
+		backups, _ := filepath.Glob(filepath.Join(backupDir, "*", "app.conf"))
+		if len(backups) != 1 {
+			t.Fatalf("Expected one backup of app.conf, got %v", backups)
+		}
+		if content, _ := os.ReadFile(backups[0]); string(content) != "hotfix" {
+			t.Errorf("Expected backup with local changes, got %q", content)
+		}
+	})
+
+	// Изменения, ожидающие отправки, не отменяются закреплением
+	t.Run("push", func(t *testing.T) {
+		gitRepo, localPath := newPushRepository(t, remote)
+		if err := os.WriteFile(filepath.Join(localPath, "app.conf"), []byte("hotfix"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := gitRepo.Pin(context.Background(), "", 1); !errors.Is(err, git.ErrLocalChanges) {
+			t.Errorf("Expected ErrLocalChanges, got %v", err)
+		}
+		if readConf(localPath) != "hotfix" || gitRepo.Pinned() != nil {
+			t.Errorf("Expected local changes to be kept, got %q", readConf(localPath))
+		}
+	})
+}
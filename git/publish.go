@@ -0,0 +1,323 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"git-sync/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// tmpSuffix - суффикс временных каталогов и ссылок, которые создаются при публикации
+const tmpSuffix = ".tmp"
+
+// publishIndexName - файл в каталоге ревизий со списком опубликованных ревизий от старых к новым.
+// Порядок публикации не зависит от времени изменения каталогов, которое меняется при копировании.
+const publishIndexName = ".published"
+
+// isPublish проверяет, включена ли публикация ревизий через символическую ссылку
+func (gitRepo *GitRepository) isPublish() bool {
+	return gitRepo.options.publishLink != ""
+}
+
+// publishRoot возвращает каталог, в котором хранятся опубликованные ревизии
+func (gitRepo *GitRepository) publishRoot() string {
+	if gitRepo.options.publishRoot != "" {
+		return gitRepo.options.publishRoot
+	}
+	return filepath.Join(filepath.Dir(gitRepo.options.publishLink), ".revisions")
+}
+
+// PublishedPath возвращает путь к каталогу текущей опубликованной ревизии
+func (gitRepo *GitRepository) PublishedPath() string {
+	if !gitRepo.isPublish() || gitRepo.currentCommit == nil {
+		return ""
+	}
+	return filepath.Join(gitRepo.publishRoot(), gitRepo.currentCommit.Hash)
+}
+
+// publishRevision записывает текущий коммит в отдельный каталог, названный по хешу коммита,
+// и атомарно переключает на него символическую ссылку. Каталог ревизии создается один раз:
+// потребители видят либо предыдущую, либо новую ревизию целиком.
+// Время выгрузки ревизии ограничено checkoutTimeout.
+func (gitRepo *GitRepository) publishRevision(ctx context.Context) error {
+
+	if !gitRepo.isPublish() || gitRepo.currentCommit == nil {
+		return nil
+	}
+
+	root := gitRepo.publishRoot()
+	hash := gitRepo.currentCommit.Hash
+	dir := filepath.Join(root, hash)
+
+	// Ревизия уже опубликована
+	if target, err := os.Readlink(gitRepo.options.publishLink); err == nil && filepath.Base(target) == hash {
+		if _, err := os.Stat(dir); err == nil {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create revisions directory: %v", err)
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		err := withTimeout(ctx, gitRepo.options.checkoutTimeout, ErrCheckoutTimeout, func(ctx context.Context) error {
+			return gitRepo.exportRevision(ctx, dir)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := swapSymlink(dir, gitRepo.options.publishLink); err != nil {
+		return err
+	}
+
+	logger.GetLogger().Info("publish %s -> %s\n", gitRepo.options.publishLink, dir)
+
+	return gitRepo.pruneRevisions(hash)
+}
+
+// exportRevision записывает файлы текущего коммита во временный каталог
+// и переименовывает его в dir после успешной записи.
+func (gitRepo *GitRepository) exportRevision(ctx context.Context, dir string) error {
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+tmpSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create revision directory: %v", err)
+	}
+
+	err = exportCommit(ctx, gitRepo.repository, plumbing.NewHash(gitRepo.currentCommit.Hash), tmp, "", gitRepo.inSparse, gitRepo.options.submodules)
+	if err == nil {
+		err = os.Chmod(tmp, 0755)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dir)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to export revision %s: %w", filepath.Base(dir), err)
+	}
+
+	return nil
+}
+
+// exportCommit записывает файлы коммита hash репозитория repository в каталог dst.
+// Файлы, для которых filter возвращает false, пропускаются. Если withSubmodules установлен,
+// в каталог рекурсивно записываются файлы подмодулей. При отмене ctx запись прерывается.
+func exportCommit(ctx context.Context, repository *git.Repository, hash plumbing.Hash, dst, prefix string, filter func(string) bool, withSubmodules bool) error {
+
+	commit, err := repository.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit object: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get tree: %v", err)
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !filter(prefix + f.Name) {
+			return nil
+		}
+		return exportFile(f, filepath.Join(dst, filepath.FromSlash(f.Name)))
+	})
+	if err != nil {
+		return err
+	}
+
+	if !withSubmodules {
+		return nil
+	}
+
+	wt, err := repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %v", err)
+	}
+
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return fmt.Errorf("failed to get submodules: %v", err)
+	}
+
+	for _, sub := range submodules {
+
+		name := sub.Config().Path
+		if !filter(prefix + name) {
+			continue
+		}
+
+		entry, err := tree.FindEntry(name)
+		if err != nil {
+			continue
+		}
+
+		subRepo, err := sub.Repository()
+		if err != nil {
+			return fmt.Errorf("failed to open submodule %s: %v", name, err)
+		}
+
+		// Фильтр путей применяется только к путям основного репозитория
+		all := func(string) bool { return true }
+		err = exportCommit(ctx, subRepo, entry.Hash, filepath.Join(dst, filepath.FromSlash(name)), prefix+name+"/", all, withSubmodules)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// swapSymlink атомарно переключает символическую ссылку link на каталог target:
+// новая ссылка создается рядом и переименовывается поверх существующей.
+func swapSymlink(target, link string) error {
+
+	// Относительная ссылка остается корректной при монтировании каталога по другому пути
+	if rel, err := filepath.Rel(filepath.Dir(link), target); err == nil {
+		target = rel
+	}
+
+	tmp := link + tmpSuffix
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove temporary link %s: %v", tmp, err)
+	}
+
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("failed to create link %s: %v", tmp, err)
+	}
+
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to publish link %s: %v", link, err)
+	}
+
+	return nil
+}
+
+// pruneRevisions удаляет старые ревизии, оставляя текущую и publishKeep самых новых из предыдущих.
+// Ревизии упорядочиваются по списку опубликованных ревизий (publishIndexName), ревизии вне списка
+// считаются самыми старыми. Оставшиеся после сбоев временные каталоги удаляются всегда.
+func (gitRepo *GitRepository) pruneRevisions(current string) error {
+
+	root := gitRepo.publishRoot()
+
+	// Текущая ревизия становится самой новой
+	var order []string
+	for _, name := range gitRepo.publishOrder() {
+		if name != current {
+			order = append(order, name)
+		}
+	}
+	order = append(order, current)
+
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i + 1
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to read revisions directory: %v", err)
+	}
+
+	var revisions []string
+	for _, entry := range entries {
+
+		name := entry.Name()
+		if name == current || !entry.IsDir() {
+			continue
+		}
+
+		if strings.Contains(name, tmpSuffix) {
+			if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+				return fmt.Errorf("failed to remove %s: %v", name, err)
+			}
+			continue
+		}
+
+		revisions = append(revisions, name)
+	}
+
+	// Сначала самые новые ревизии
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return rank[revisions[i]] > rank[revisions[j]]
+	})
+
+	removed := make(map[string]bool)
+	for i, name := range revisions {
+		if i < gitRepo.options.publishKeep {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+			return fmt.Errorf("failed to remove revision %s: %v", name, err)
+		}
+		removed[name] = true
+		logger.GetLogger().Info("prune revision %s\n", name)
+	}
+
+	// Сохраняем порядок оставшихся ревизий
+	kept := order[:0]
+	for _, name := range order {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil && !removed[name] {
+			kept = append(kept, name)
+		}
+	}
+
+	return gitRepo.writePublishOrder(kept)
+}
+
+// publishOrder возвращает список опубликованных ревизий от старых к новым
+func (gitRepo *GitRepository) publishOrder() []string {
+
+	data, err := os.ReadFile(filepath.Join(gitRepo.publishRoot(), publishIndexName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.GetLogger().Warning("failed to read published revisions: %v\n", err)
+		}
+		return nil
+	}
+
+	return strings.Fields(string(data))
+}
+
+// writePublishOrder атомарно записывает список опубликованных ревизий от старых к новым
+func (gitRepo *GitRepository) writePublishOrder(order []string) error {
+
+	path := filepath.Join(gitRepo.publishRoot(), publishIndexName)
+
+	data := strings.Join(order, "\n") + "\n"
+	if err := os.WriteFile(path+tmpSuffix, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write published revisions: %v", err)
+	}
+	if err := os.Rename(path+tmpSuffix, path); err != nil {
+		os.Remove(path + tmpSuffix)
+		return fmt.Errorf("failed to write published revisions: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPublishRevisions(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add config", map[string]string{"conf/app.conf": "v1"})
+
+	base := t.TempDir()
+	link := filepath.Join(base, "current")
+	root := filepath.Join(base, "revisions")
+
+	mockFlags := newTestFlags(t, remote, filepath.Join(base, "repo"))
+	mockFlags.String(constants.FlagPublishLink, "", "Publish link")
+	mockFlags.String(constants.FlagPublishRoot, "", "Publish root")
+	mockFlags.Int(constants.FlagPublishKeep, 0, "Publish keep")
+
+	gitRepo := newTestRepository(t, mockFlags,
+		"--"+constants.FlagPublishLink+"="+link,
+		"--"+constants.FlagPublishRoot+"="+root,
+		"--"+constants.FlagPublishKeep+"=1")
+
+	// Ссылка указывает на каталог ревизии, названный по хешу коммита
+	checkPublished := func(hash, content string) {
+		t.Helper()
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatalf("Expected %s to be a symlink: %v", link, err)
+		}
+		if filepath.Base(target) != hash {
+			t.Errorf("Expected link to point to %s, got %s", hash, target)
+		}
+		if gitRepo.PublishedPath() != filepath.Join(root, hash) {
+			t.Errorf("Expected published path %s, got %s", filepath.Join(root, hash), gitRepo.PublishedPath())
+		}
+		data, err := os.ReadFile(filepath.Join(link, "conf/app.conf"))
+		if err != nil || string(data) != content {
+			t.Errorf("Expected conf/app.conf %q, got %q (%v)", content, data, err)
+		}
+	}
+
+	first := gitRepo.CommitHash()
+	checkPublished(first, "v1")
+
+	// Каждая новая ревизия публикуется в своем каталоге, предыдущая сохраняется
+	second := remote.commit("update config", map[string]string{"conf/app.conf": "v2"}).String()
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	checkPublished(second, "v2")
+	if data, _ := os.ReadFile(filepath.Join(root, first, "conf/app.conf")); string(data) != "v1" {
+		t.Errorf("Expected previous revision to stay unchanged, got %q", data)
+	}
+
+	// Хранится только одна предыдущая ревизия. Порядок определяется публикацией,
+	// а не временем изменения каталога (например, после копирования или восстановления).
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, first), future, future); err != nil {
+		t.Fatal(err)
+	}
+	third := remote.commit("update config again", map[string]string{"conf/app.conf": "v3"}).String()
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	checkPublished(third, "v3")
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) != 2 {
+		t.Errorf("Expected revisions %s and %s, got %v", second, third, names)
+	}
+	if _, err := os.Stat(filepath.Join(root, first)); !os.IsNotExist(err) {
+		t.Errorf("Expected revision %s to be pruned", first)
+	}
+}
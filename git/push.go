@@ -0,0 +1,649 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/internal/constants"
+	"git-sync/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Результаты отправки локальных изменений
+const (
+	PushPushed   string = "pushed"   // изменения отправлены
+	PushConflict string = "conflict" // изменения конфликтуют с удаленной веткой
+	PushFailed   string = "failed"   // отправка завершилась ошибкой
+)
+
+// ErrPushConflict возвращается, если локальные и удаленные изменения затрагивают одни и те же файлы.
+// Локальные изменения при этом не отменяются.
+var ErrPushConflict = errors.New("push conflict")
+
+// ErrPushRejected возвращается, если удаленный репозиторий отклонил отправку
+// (например, ветка изменилась после получения изменений).
+var ErrPushRejected = errors.New("push rejected")
+
+// pushRef - временная ссылка на отправляемый коммит
+const pushRef = "refs/git-sync/push"
+
+// PushInfo содержит информацию об отправке локальных изменений
+type PushInfo struct {
+	Branch    string    `json:"branch"`              // Ветка удаленного репозитория
+	Strategy  string    `json:"strategy"`            // Способ объединения (rebase, merge)
+	Result    string    `json:"result"`              // Результат (pushed, conflict, failed)
+	Commit    string    `json:"commit,omitempty"`    // Отправленный коммит
+	Files     []string  `json:"files"`               // Локальные изменения
+	Conflicts []string  `json:"conflicts,omitempty"` // Файлы, измененные локально и в удаленной ветке
+	Time      time.Time `json:"time"`
+}
+
+// pushMessageData - данные для шаблона сообщения коммита с локальными изменениями
+type pushMessageData struct {
+	Files    []string
+	Count    int
+	Branch   string
+	Hostname string
+	Time     time.Time
+}
+
+// pushTarget возвращает ветку удаленного репозитория для отправки локальных изменений
+func (gitRepo *GitRepository) pushTarget() string {
+	if gitRepo.options.pushBranch != "" {
+		return gitRepo.options.pushBranch
+	}
+	return gitRepo.options.branch
+}
+
+// pushLocalChanges фиксирует локальные изменения (включая неотслеживаемые файлы) и отправляет их
+// в удаленный репозиторий.
+//
+// Для отслеживаемой ветки коммит переносится на удаленную ветку (rebase) либо объединяется с ней (merge),
+// если она изменилась. Если локальные и удаленные изменения затрагивают одни и те же файлы,
+// возвращается ErrPushConflict, а рабочий каталог не изменяется. После отправки рабочий каталог
+// переключается на отправленный коммит.
+//
+// Для отдельной ветки (pushBranch) локальные изменения фиксируются поверх последнего коммита этой ветки
+// в удаленном репозитории (или поверх текущего коммита, если ветки еще нет), а рабочий каталог
+// возвращается к отслеживаемой ветке. Отправка выполняется только как fast-forward: если ветка
+// изменилась после получения изменений, возвращается ErrPushRejected и локальные изменения сохраняются.
+func (gitRepo *GitRepository) pushLocalChanges(ctx context.Context) error {
+
+	if !gitRepo.options.push {
+		return nil
+	}
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %v", err)
+	}
+
+	files := append(gitRepo.changedFiles(status), untrackedFiles(status)...)
+	if len(files) == 0 {
+		return nil
+	}
+	sort.Strings(files)
+
+	target := gitRepo.pushTarget()
+
+	push := &PushInfo{
+		Branch:   target,
+		Strategy: gitRepo.options.pushStrategy,
+		Result:   PushFailed,
+		Files:    files,
+		Time:     time.Now(),
+	}
+	defer gitRepo.setLastPush(push)
+
+	base, err := gitRepo.getCommit(false)
+	if err != nil {
+		return err
+	}
+
+	baseTree, err := base.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get local tree: %v", err)
+	}
+
+	// Сохраняем содержимое измененных файлов в хранилище объектов, рабочий каталог не изменяется
+	entries, err := gitRepo.storeFiles(files)
+	if err != nil {
+		return err
+	}
+
+	message, err := gitRepo.pushCommitMessage(files, target)
+	if err != nil {
+		return err
+	}
+
+	tree, err := gitRepo.storeTree(baseTree, entries)
+	if err != nil {
+		return err
+	}
+
+	local, err := gitRepo.storeCommit(tree, message, base.Hash)
+	if err != nil {
+		return err
+	}
+
+	var result plumbing.Hash
+	if target == gitRepo.options.branch {
+		result, err = gitRepo.integrateRemote(ctx, base, local, entries, message, push)
+	} else {
+		result, err = gitRepo.pushBranchCommit(ctx, target, local, entries, message)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = gitRepo.pushCommit(ctx, result, target)
+	if err != nil {
+		return err
+	}
+
+	push.Result = PushPushed
+	push.Commit = result.String()
+
+	logger.GetLogger().Info("push %s %s (%d files)\n", target, result, len(files))
+
+	gitRepo.setChangesFlag(true)
+
+	if target != gitRepo.options.branch {
+		// Изменения сохранены в отдельной ветке, рабочий каталог возвращается к отслеживаемой ветке
+		if err := gitRepo.discardLocalChanges(status, files); err != nil {
+			return err
+		}
+		gitRepo.storeCurrentCommit("push")
+		return gitRepo.showCommitMessage()
+	}
+
+	return gitRepo.checkoutPushed(base, result)
+}
+
+// integrateRemote объединяет коммит local с локальными изменениями entries с удаленной веткой,
+// если она изменилась относительно коммита base. Возвращает коммит для отправки.
+func (gitRepo *GitRepository) integrateRemote(ctx context.Context, base *object.Commit, local plumbing.Hash,
+	entries map[string]*object.TreeEntry, message string, push *PushInfo) (plumbing.Hash, error) {
+
+	remote, err := gitRepo.getCommit(true)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if remote.Hash == base.Hash {
+		return local, nil
+	}
+
+	var (
+		remoteTree *object.Tree
+		conflicts  []string
+	)
+
+	// Ищем файлы, измененные и локально, и в удаленной ветке
+	err = gitRepo.withHistory(ctx, func() error {
+		baseTree, err := base.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get local tree: %w", err)
+		}
+		remoteTree, err = remote.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get remote tree: %w", err)
+		}
+		diff, err := baseTree.Diff(remoteTree)
+		if err != nil {
+			return fmt.Errorf("failed to get diff: %w", err)
+		}
+		conflicts = pushConflicts(diff, remoteTree, entries)
+		return nil
+	})
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if len(conflicts) > 0 {
+		push.Result = PushConflict
+		push.Conflicts = conflicts
+		logger.GetLogger().Error("push %s conflicts with remote changes: %s\n", push.Branch, strings.Join(conflicts, ", "))
+		return plumbing.ZeroHash, fmt.Errorf("%w: %s", ErrPushConflict, strings.Join(conflicts, ", "))
+	}
+
+	tree, err := gitRepo.storeTree(remoteTree, entries)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if gitRepo.options.pushStrategy == constants.PushMerge {
+		mergeMessage := fmt.Sprintf("Merge remote-tracking branch '%s/%s'", gitRepo.options.originName, gitRepo.options.branch)
+		return gitRepo.storeCommit(tree, mergeMessage, local, remote.Hash)
+	}
+
+	return gitRepo.storeCommit(tree, message, remote.Hash)
+}
+
+// pushBranchCommit возвращает коммит для отправки в отдельную ветку branch: локальные изменения entries
+// поверх последнего коммита ветки в удаленном репозитории, чтобы не перезаписывать ранее отправленные
+// изменения. Если ветки еще нет, возвращается коммит local поверх текущего коммита.
+func (gitRepo *GitRepository) pushBranchCommit(ctx context.Context, branch string, local plumbing.Hash,
+	entries map[string]*object.TreeEntry, message string) (plumbing.Hash, error) {
+
+	remoteRef := plumbing.NewRemoteReferenceName(gitRepo.options.originName, branch)
+	ref, err := gitRepo.repository.Reference(remoteRef, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return local, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get reference: %v", err)
+	}
+
+	var tree plumbing.Hash
+	err = gitRepo.withHistory(ctx, func() error {
+		head, err := gitRepo.repository.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to get commit object: %w", err)
+		}
+		headTree, err := head.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to get tree of %s: %w", branch, err)
+		}
+		tree, err = gitRepo.storeTree(headTree, entries)
+		return err
+	})
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return gitRepo.storeCommit(tree, message, ref.Hash())
+}
+
+// pushConflicts возвращает отсортированный список файлов, которые изменены и локально (entries),
+// и в удаленной ветке (diff), причем с разным результатом
+func pushConflicts(diff object.Changes, remoteTree *object.Tree, entries map[string]*object.TreeEntry) []string {
+
+	seen := make(map[string]bool)
+	var conflicts []string
+
+	for _, change := range diff {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+
+			entry, ok := entries[name]
+			if name == "" || !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			remoteEntry, err := remoteTree.FindEntry(name)
+			if err != nil {
+				remoteEntry = nil
+			}
+
+			// Одинаковые изменения не считаются конфликтом
+			if entry == nil && remoteEntry == nil {
+				continue
+			}
+			if entry != nil && remoteEntry != nil && entry.Hash == remoteEntry.Hash && entry.Mode == remoteEntry.Mode {
+				continue
+			}
+
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	sort.Strings(conflicts)
+
+	return conflicts
+}
+
+// pushCommitMessage формирует сообщение коммита с локальными изменениями по шаблону
+func (gitRepo *GitRepository) pushCommitMessage(files []string, branch string) (string, error) {
+
+	hostname, _ := os.Hostname()
+
+	var message bytes.Buffer
+	err := gitRepo.options.pushMessage.Execute(&message, pushMessageData{
+		Files:    files,
+		Count:    len(files),
+		Branch:   branch,
+		Hostname: hostname,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render push message: %v", err)
+	}
+
+	return message.String(), nil
+}
+
+// storeFiles сохраняет файлы рабочего каталога в хранилище объектов.
+// Возвращает элементы дерева для файлов; удаленным файлам соответствует nil.
+// Каталоги (подмодули) пропускаются.
+func (gitRepo *GitRepository) storeFiles(files []string) (map[string]*object.TreeEntry, error) {
+
+	entries := make(map[string]*object.TreeEntry, len(files))
+
+	for _, name := range files {
+
+		path := filepath.Join(gitRepo.options.path, filepath.FromSlash(name))
+
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			entries[name] = nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %v", name, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		var content []byte
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read link %s: %v", name, err)
+			}
+			content = []byte(target)
+		} else {
+			content, err = os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", name, err)
+			}
+		}
+
+		mode, err := filemode.NewFromOSFileMode(info.Mode())
+		if err != nil {
+			return nil, fmt.Errorf("unsupported file mode of %s: %v", name, err)
+		}
+
+		obj := gitRepo.repository.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		w, err := obj.Writer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to store %s: %v", name, err)
+		}
+		if _, err := w.Write(content); err != nil {
+			w.Close()
+			return nil, fmt.Errorf("failed to store %s: %v", name, err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to store %s: %v", name, err)
+		}
+
+		hash, err := gitRepo.repository.Storer.SetEncodedObject(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store %s: %v", name, err)
+		}
+
+		entries[name] = &object.TreeEntry{Mode: mode, Hash: hash}
+	}
+
+	return entries, nil
+}
+
+// storeTree сохраняет в хранилище объектов дерево tree с измененными файлами entries
+// (nil - файл удален) и возвращает его хеш. Пустые каталоги удаляются.
+func (gitRepo *GitRepository) storeTree(tree *object.Tree, entries map[string]*object.TreeEntry) (plumbing.Hash, error) {
+	hash, _, err := gitRepo.storeSubtree(tree, entries)
+	return hash, err
+}
+
+// storeSubtree сохраняет дерево каталога и возвращает его хеш и количество элементов
+func (gitRepo *GitRepository) storeSubtree(tree *object.Tree, entries map[string]*object.TreeEntry) (plumbing.Hash, int, error) {
+
+	items := make(map[string]object.TreeEntry)
+	if tree != nil {
+		for _, entry := range tree.Entries {
+			items[entry.Name] = entry
+		}
+	}
+
+	// Изменения файлов текущего каталога применяются сразу, вложенных - группируются по каталогам
+	subdirs := make(map[string]map[string]*object.TreeEntry)
+	for name, entry := range entries {
+		dir, rest, nested := strings.Cut(name, "/")
+		if nested {
+			if subdirs[dir] == nil {
+				subdirs[dir] = make(map[string]*object.TreeEntry)
+			}
+			subdirs[dir][rest] = entry
+			continue
+		}
+		if entry == nil {
+			delete(items, name)
+			continue
+		}
+		items[name] = object.TreeEntry{Name: name, Mode: entry.Mode, Hash: entry.Hash}
+	}
+
+	for dir, dirEntries := range subdirs {
+
+		var subtree *object.Tree
+		if item, ok := items[dir]; ok && item.Mode == filemode.Dir {
+			var err error
+			subtree, err = object.GetTree(gitRepo.repository.Storer, item.Hash)
+			if err != nil {
+				return plumbing.ZeroHash, 0, fmt.Errorf("failed to get tree %s: %v", dir, err)
+			}
+		}
+
+		hash, count, err := gitRepo.storeSubtree(subtree, dirEntries)
+		if err != nil {
+			return plumbing.ZeroHash, 0, err
+		}
+		if count == 0 {
+			delete(items, dir)
+			continue
+		}
+		items[dir] = object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash}
+	}
+
+	result := &object.Tree{}
+	for _, item := range items {
+		result.Entries = append(result.Entries, item)
+	}
+
+	// Git сортирует элементы дерева по имени, считая, что имя каталога оканчивается на "/"
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+		return entry.Name
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		return sortKey(result.Entries[i]) < sortKey(result.Entries[j])
+	})
+
+	obj := gitRepo.repository.Storer.NewEncodedObject()
+	if err := result.Encode(obj); err != nil {
+		return plumbing.ZeroHash, 0, fmt.Errorf("failed to encode tree: %v", err)
+	}
+	hash, err := gitRepo.repository.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, 0, fmt.Errorf("failed to store tree: %v", err)
+	}
+
+	return hash, len(result.Entries), nil
+}
+
+// storeCommit сохраняет в хранилище объектов коммит с деревом tree и родителями parents
+func (gitRepo *GitRepository) storeCommit(tree plumbing.Hash, message string, parents ...plumbing.Hash) (plumbing.Hash, error) {
+
+	signature := object.Signature{
+		Name:  gitRepo.options.pushAuthorName,
+		Email: gitRepo.options.pushAuthorEmail,
+		When:  time.Now(),
+	}
+
+	commit := &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: parents,
+	}
+
+	obj := gitRepo.repository.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to encode commit: %v", err)
+	}
+	hash, err := gitRepo.repository.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store commit: %v", err)
+	}
+
+	return hash, nil
+}
+
+// pushCommit отправляет коммит hash в ветку branch удаленного репозитория без перезаписи (только fast-forward).
+// Время отправки ограничено временем получения изменений (fetchTimeout), превышение сообщается как ErrPushTimeout.
+func (gitRepo *GitRepository) pushCommit(ctx context.Context, hash plumbing.Hash, branch string) error {
+
+	remote, err := gitRepo.repository.Remote(gitRepo.options.originName)
+	if err != nil {
+		return fmt.Errorf("failed to get remote: %v", err)
+	}
+
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		return err
+	}
+
+	// Отправлять можно только ссылку, поэтому коммит временно записывается в служебную ссылку
+	ref := plumbing.NewHashReference(pushRef, hash)
+	if err := gitRepo.repository.Storer.SetReference(ref); err != nil {
+		return fmt.Errorf("failed to store push reference: %v", err)
+	}
+	defer gitRepo.repository.Storer.RemoveReference(ref.Name())
+
+	refSpec := fmt.Sprintf("%s:%s", ref.Name(), plumbing.NewBranchReferenceName(branch))
+
+	return withTimeout(ctx, gitRepo.options.fetchTimeout, ErrPushTimeout, func(ctx context.Context) error {
+		err := remote.PushContext(ctx, &git.PushOptions{
+			RemoteName: gitRepo.options.originName,
+			RefSpecs:   []config.RefSpec{config.RefSpec(refSpec)},
+			Auth:       auth,
+		})
+
+		if err == git.NoErrAlreadyUpToDate {
+			return nil
+		}
+		if errors.Is(err, git.ErrNonFastForwardUpdate) {
+			return fmt.Errorf("%w: %s: %w", ErrPushRejected, branch, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to push to %s: %w", branch, err)
+		}
+
+		return nil
+	})
+}
+
+// checkoutPushed переключает рабочий каталог на отправленный в отслеживаемую ветку коммит
+// и сохраняет его как текущий с изменениями относительно коммита base
+func (gitRepo *GitRepository) checkoutPushed(base *object.Commit, hash plumbing.Hash) error {
+
+	commit, err := gitRepo.repository.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit object: %v", err)
+	}
+
+	// Отслеживаемая ветка удаленного репозитория теперь указывает на отправленный коммит
+	remoteRef := plumbing.NewRemoteReferenceName(gitRepo.options.originName, gitRepo.options.branch)
+	if err := gitRepo.repository.Storer.SetReference(plumbing.NewHashReference(remoteRef, hash)); err != nil {
+		return fmt.Errorf("failed to update remote reference: %v", err)
+	}
+
+	if err := gitRepo.resetBranch(commit); err != nil {
+		return err
+	}
+
+	baseTree, err := base.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get local tree: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get pushed tree: %v", err)
+	}
+	diff, err := baseTree.Diff(tree)
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %v", err)
+	}
+	changes, err := gitRepo.changesInfo(diff)
+	if err != nil {
+		return err
+	}
+
+	gitRepo.storeCurrentCommit("push", changes...)
+
+	return gitRepo.showCommitMessage()
+}
+
+// discardLocalChanges отменяет отправленные локальные изменения: сбрасывает отслеживаемые файлы
+// и удаляет неотслеживаемые
+func (gitRepo *GitRepository) discardLocalChanges(status git.Status, files []string) error {
+
+	if err := gitRepo.resetRepo(); err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		if status.File(name).Worktree != git.Untracked {
+			continue
+		}
+		if err := os.Remove(filepath.Join(gitRepo.options.path, filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setLastPush сохраняет информацию об отправке локальных изменений
+func (gitRepo *GitRepository) setLastPush(push *PushInfo) {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	gitRepo.lastPush = push
+}
+
+// resetLastPush сбрасывает информацию об отправке перед синхронизацией
+func (gitRepo *GitRepository) resetLastPush() {
+	gitRepo.setLastPush(nil)
+}
+
+// LastPush возвращает информацию об отправке локальных изменений при последней синхронизации
+func (gitRepo *GitRepository) LastPush() *PushInfo {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	return gitRepo.lastPush
+}
@@ -0,0 +1,289 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newPushRepository клонирует удаленный репозиторий в режиме отправки локальных изменений
+func newPushRepository(t *testing.T, remote *remoteRepo, args ...string) (*git.GitRepository, string) {
+
+	// Удаленный репозиторий не bare, разрешаем отправку в текущую ветку
+	cfg, err := remote.repository.Config()
+	if err != nil {
+		t.Fatalf("Error reading remote config: %v", err)
+	}
+	cfg.Raw.Section("receive").SetOption("denyCurrentBranch", "ignore")
+	if err := remote.repository.SetConfig(cfg); err != nil {
+		t.Fatalf("Error writing remote config: %v", err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagPush, false, "Push local changes")
+	mockFlags.String(constants.FlagPushBranch, "", "Push branch")
+	mockFlags.String(constants.FlagPushStrategy, constants.PushRebase, "Push strategy")
+	mockFlags.String(constants.FlagPushMessage, constants.PushMessage, "Push message")
+	mockFlags.String(constants.FlagPushConflict, constants.PushConflictFail, "Push conflict policy")
+	mockFlags.String(constants.FlagLocalChanges, constants.LocalChangesReset, "Local changes policy")
+	mockFlags.String(constants.FlagLocalBackupDir, "", "Local backup dir")
+
+	return newTestRepository(t, mockFlags, append(args, "--"+constants.FlagPush)...), localPath
+}
+
+// branchCommit возвращает последний коммит ветки удаленного репозитория
+func (r *remoteRepo) branchCommit(branch string) *object.Commit {
+
+	ref, err := r.repository.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		r.t.Fatalf("Error getting branch %s: %v", branch, err)
+	}
+	commit, err := r.repository.CommitObject(ref.Hash())
+	if err != nil {
+		r.t.Fatalf("Error getting commit %s: %v", ref.Hash(), err)
+	}
+
+	return commit
+}
+
+// fileContent возвращает содержимое файла коммита или пустую строку, если файла нет
+func fileContent(t *testing.T, commit *object.Commit, name string) string {
+	t.Helper()
+	file, err := commit.File(name)
+	if err != nil {
+		return ""
+	}
+	content, err := file.Contents()
+	if err != nil {
+		t.Fatalf("Error reading %s: %v", name, err)
+	}
+	return content
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushLocalChanges(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	gitRepo, localPath := newPushRepository(t, remote,
+		"--"+constants.FlagPushMessage+"=state: {{range .Files}}{{.}} {{end}}")
+
+	writeFile(t, localPath, "README.md", "updated")
+	writeFile(t, localPath, "state/generated.json", "{}")
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	head := remote.branchCommit("master")
+	if head.Message != "state: README.md state/generated.json " {
+		t.Errorf("Unexpected commit message %q", head.Message)
+	}
+	if fileContent(t, head, "README.md") != "updated" || fileContent(t, head, "state/generated.json") != "{}" {
+		t.Errorf("Expected local changes to be pushed")
+	}
+	if gitRepo.CommitHash() != head.Hash.String() {
+		t.Errorf("Expected local commit %s, got %s", head.Hash, gitRepo.CommitHash())
+	}
+
+	push := gitRepo.LastPush()
+	if push == nil || push.Result != git.PushPushed || push.Commit != head.Hash.String() || len(push.Files) != 2 {
+		t.Errorf("Unexpected push info: %+v", push)
+	}
+
+	// Без локальных изменений ничего не отправляется
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.LastPush() != nil || remote.branchCommit("master").Hash != head.Hash {
+		t.Errorf("Expected nothing to be pushed")
+	}
+}
+
+func TestPushStrategies(t *testing.T) {
+
+	for _, strategy := range []string{constants.PushRebase, constants.PushMerge} {
+		t.Run(strategy, func(t *testing.T) {
+
+			remote := newRemoteRepo(t)
+			gitRepo, localPath := newPushRepository(t, remote, "--"+constants.FlagPushStrategy+"="+strategy)
+
+			writeFile(t, localPath, "state.json", "local")
+			remoteHash := remote.commit("remote change", map[string]string{"app.txt": "remote"})
+
+			if err := gitRepo.Sync(context.Background()); err != nil {
+				t.Fatalf("Error syncing repository: %v", err)
+			}
+
+			head := remote.branchCommit("master")
+			if fileContent(t, head, "state.json") != "local" || fileContent(t, head, "app.txt") != "remote" {
+				t.Errorf("Expected both local and remote changes in %s", head.Hash)
+			}
+
+			parents := 1
+			if strategy == constants.PushMerge {
+				parents = 2
+			}
+			if len(head.ParentHashes) != parents || head.ParentHashes[len(head.ParentHashes)-1] != remoteHash {
+				t.Errorf("Expected %d parents ending with %s, got %v", parents, remoteHash, head.ParentHashes)
+			}
+
+			// Рабочий каталог содержит удаленные изменения
+			if content, _ := os.ReadFile(filepath.Join(localPath, "app.txt")); string(content) != "remote" {
+				t.Errorf("Expected remote changes in worktree, got %q", content)
+			}
+		})
+	}
+}
+
+func TestPushConflict(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	gitRepo, localPath := newPushRepository(t, remote)
+
+	writeFile(t, localPath, "README.md", "local")
+	remoteHash := remote.commit("remote change", map[string]string{"README.md": "remote"})
+
+	err := gitRepo.Sync(context.Background())
+	if !errors.Is(err, git.ErrPushConflict) {
+		t.Fatalf("Expected ErrPushConflict, got %v", err)
+	}
+
+	push := gitRepo.LastPush()
+	if push == nil || push.Result != git.PushConflict || len(push.Conflicts) != 1 || push.Conflicts[0] != "README.md" {
+		t.Errorf("Unexpected push info: %+v", push)
+	}
+
+	// Локальные изменения не отменяются, удаленная ветка не изменяется
+	if content, _ := os.ReadFile(filepath.Join(localPath, "README.md")); string(content) != "local" {
+		t.Errorf("Expected local changes to be kept, got %q", content)
+	}
+	if remote.branchCommit("master").Hash != remoteHash {
+		t.Errorf("Expected remote branch to be unchanged")
+	}
+}
+
+func TestPushConflictFallback(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	backupDir := t.TempDir()
+	gitRepo, localPath := newPushRepository(t, remote,
+		"--"+constants.FlagPushConflict+"="+constants.PushConflictFallback,
+		"--"+constants.FlagLocalChanges+"="+constants.LocalChangesBackup,
+		"--"+constants.FlagLocalBackupDir+"="+backupDir)
+
+	writeFile(t, localPath, "README.md", "local")
+	remoteHash := remote.commit("remote change", map[string]string{"README.md": "remote"})
+
+	// Конфликт не останавливает синхронизацию: локальные изменения сохраняются в резервной копии,
+	// применяется удаленная ветка
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if push := gitRepo.LastPush(); push == nil || push.Result != git.PushConflict {
+		t.Errorf("Expected push conflict to be reported, got %+v", push)
+	}
+	if gitRepo.CommitHash() != remoteHash.String() {
+		t.Errorf("Expected remote commit %s, got %s", remoteHash, gitRepo.CommitHash())
+	}
+	if content, _ := os.ReadFile(filepath.Join(localPath, "README.md")); string(content) != "remote" {
+		t.Errorf("Expected remote changes in worktree, got %q", content)
+	}
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "*", "README.md"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup of README.md, got %v", backups)
+	}
+
+	// Следующие удаленные изменения применяются как обычно
+	next := remote.commit("next", map[string]string{"app.txt": "next"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != next.String() {
+		t.Errorf("Expected remote commit %s, got %s", next, gitRepo.CommitHash())
+	}
+}
+
+func TestPushBranch(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	master := remote.branchCommit("master").Hash
+	gitRepo, localPath := newPushRepository(t, remote, "--"+constants.FlagPushBranch+"=state")
+
+	writeFile(t, localPath, "state.json", "first")
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	state := remote.branchCommit("state")
+	if fileContent(t, state, "state.json") != "first" || state.ParentHashes[0] != master {
+		t.Errorf("Expected local changes on top of master in branch state")
+	}
+	if remote.branchCommit("master").Hash != master {
+		t.Errorf("Expected master to be unchanged")
+	}
+	if _, err := os.Stat(filepath.Join(localPath, "state.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected pushed file to be removed from worktree")
+	}
+
+	// Коммит другого автора в ветке state
+	wt, err := remote.repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("state")}); err != nil {
+		t.Fatal(err)
+	}
+	other := remote.commit("notes", map[string]string{"notes.txt": "notes"})
+	if err := wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("master")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Следующая отправка продолжает ветку, не перезаписывая ее
+	writeFile(t, localPath, "state.json", "second")
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	state = remote.branchCommit("state")
+	if fileContent(t, state, "state.json") != "second" || state.ParentHashes[0] != other {
+		t.Errorf("Expected local changes on top of %s in branch state", other)
+	}
+	if fileContent(t, state, "notes.txt") != "notes" {
+		t.Errorf("Expected changes of other authors to be kept in branch state")
+	}
+	if _, err := os.Stat(filepath.Join(localPath, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected worktree to follow master")
+	}
+}
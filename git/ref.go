@@ -0,0 +1,252 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Ref возвращает описание отслеживаемой ссылки: ветки, тега, шаблона тегов или коммита
+func (gitRepoOptions *GitRepositoryOptions) Ref() string {
+	switch {
+	case gitRepoOptions.commit != "":
+		return "commit:" + gitRepoOptions.commit
+	case gitRepoOptions.tag != "" && gitRepoOptions.tagConstraint != "":
+		return fmt.Sprintf("tag:%s (%s)", gitRepoOptions.tag, gitRepoOptions.tagConstraint)
+	case gitRepoOptions.tagConstraint != "":
+		return "tag:" + gitRepoOptions.tagConstraint
+	case gitRepoOptions.tag != "":
+		return "tag:" + gitRepoOptions.tag
+	default:
+		return "branch:" + gitRepoOptions.branch
+	}
+}
+
+// isBranch проверяет, отслеживается ли ветка
+func (gitRepoOptions *GitRepositoryOptions) isBranch() bool {
+	return gitRepoOptions.tag == "" && gitRepoOptions.tagConstraint == "" && gitRepoOptions.commit == ""
+}
+
+// isLatestTag проверяет, отслеживается ли последний тег, подходящий под шаблон или ограничение версий
+func (gitRepoOptions *GitRepositoryOptions) isLatestTag() bool {
+	return gitRepoOptions.tagConstraint != "" || strings.ContainsAny(gitRepoOptions.tag, "*?[")
+}
+
+// tagMode возвращает режим получения тегов из удаленного репозитория
+func (gitRepoOptions *GitRepositoryOptions) tagMode() git.TagMode {
+	if gitRepoOptions.isBranch() {
+		return git.TagFollowing
+	}
+	return git.AllTags
+}
+
+// getRemoteCommit возвращает коммит отслеживаемой ссылки удаленного репозитория
+// и имя тега, если отслеживается тег.
+func (gitRepo *GitRepository) getRemoteCommit() (*object.Commit, string, error) {
+
+	options := gitRepo.options
+
+	switch {
+	case options.isBranch():
+		commit, err := gitRepo.getCommit(true)
+		return commit, "", err
+
+	case options.commit != "":
+		hash, err := gitRepo.repository.ResolveRevision(plumbing.Revision(options.commit))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve commit %s: %w", options.commit, err)
+		}
+		commit, err := gitRepo.repository.CommitObject(*hash)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get commit object: %w", err)
+		}
+		return commit, "", nil
+
+	case options.isLatestTag():
+		return gitRepo.getLatestTagCommit()
+
+	default:
+		ref, err := gitRepo.repository.Tag(options.tag)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get tag %s: %v", options.tag, err)
+		}
+		commit, err := gitRepo.getTagCommit(ref)
+		return commit, options.tag, err
+	}
+}
+
+// getLatestTagCommit возвращает коммит самого нового тега, который подходит под шаблон
+// и ограничение версий. Если подходящий тег старше текущего, остается текущий тег:
+// синхронизация выполняется только вперед.
+func (gitRepo *GitRepository) getLatestTagCommit() (*object.Commit, string, error) {
+
+	options := gitRepo.options
+
+	tags, err := gitRepo.repository.Tags()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get tags: %v", err)
+	}
+
+	var (
+		latestRef     *plumbing.Reference
+		latestVersion version
+	)
+
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+
+		name := ref.Name().Short()
+
+		if options.tag != "" {
+			if ok, _ := path.Match(options.tag, name); !ok {
+				return nil
+			}
+		}
+
+		v, ok := parseVersion(name)
+		if !ok {
+			return nil
+		}
+
+		if options.versionConstraint != nil && !options.versionConstraint.check(v) {
+			return nil
+		}
+
+		if latestRef == nil || v.compare(latestVersion) > 0 {
+			latestRef, latestVersion = ref, v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to iterate tags: %v", err)
+	}
+
+	// Текущий тег новее найденного (например, найденный тег был удален)
+	if current, ok := parseVersion(gitRepo.currentTag); ok && gitRepo.currentTag != "" {
+		if latestRef == nil || current.compare(latestVersion) > 0 {
+			commit, err := gitRepo.getCommit(false)
+			return commit, gitRepo.currentTag, err
+		}
+	}
+
+	if latestRef == nil {
+		return nil, "", fmt.Errorf("no tags matching %s", options.Ref())
+	}
+
+	commit, err := gitRepo.getTagCommit(latestRef)
+	return commit, latestRef.Name().Short(), err
+}
+
+// getTagCommit возвращает коммит, на который указывает легковесный или аннотированный тег
+func (gitRepo *GitRepository) getTagCommit(ref *plumbing.Reference) (*object.Commit, error) {
+
+	if tag, err := gitRepo.repository.TagObject(ref.Hash()); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit of tag %s: %w", ref.Name().Short(), err)
+		}
+		return commit, nil
+	}
+
+	commit, err := gitRepo.repository.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit object: %w", err)
+	}
+
+	return commit, nil
+}
+
+// checkoutRemote переключает локальный репозиторий на коммит отслеживаемой ссылки.
+// Объекты уже получены fetchRepo, поэтому ветка переключается жестким сбросом на коммит
+// без повторного обращения к серверу; теги и коммиты извлекаются в состоянии detached HEAD.
+// Отмена ctx прерывает запись файлов частичного checkout; reset и checkout go-git не прерываются.
+func (gitRepo *GitRepository) checkoutRemote(ctx context.Context, commit *object.Commit, changes object.Changes) error {
+
+	if gitRepo.isSparse() {
+		if !gitRepo.options.isBranch() {
+			if err := gitRepo.detachHead(commit.Hash); err != nil {
+				return err
+			}
+		}
+		return gitRepo.checkoutSparse(ctx, commit, changes)
+	}
+
+	if gitRepo.options.isBranch() {
+		// переключаем ветку на удаленный коммит (git reset --hard), в том числе при fast-forward
+		return gitRepo.resetBranch(commit)
+	}
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	err = wt.Checkout(&git.CheckoutOptions{
+		Hash:  commit.Hash,
+		Force: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to checkout %s: %v", commit.Hash, err)
+	}
+
+	return nil
+}
+
+// checkoutInitial переключает только что клонированный репозиторий на отслеживаемую ссылку
+// и записывает в рабочий каталог файлы частичного checkout.
+func (gitRepo *GitRepository) checkoutInitial(ctx context.Context) error {
+
+	if gitRepo.options.isBranch() && !gitRepo.isSparse() {
+		return nil
+	}
+
+	var (
+		commit *object.Commit
+		tag    string
+		err    error
+	)
+
+	if gitRepo.options.isBranch() {
+		commit, err = gitRepo.getCommit(false)
+	} else {
+		commit, tag, err = gitRepo.getRemoteCommit()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := gitRepo.checkoutRemote(ctx, commit, nil); err != nil {
+		return err
+	}
+
+	gitRepo.currentTag = tag
+
+	return nil
+}
+
+// detachHead переводит HEAD в состояние detached на указанный коммит
+func (gitRepo *GitRepository) detachHead(hash plumbing.Hash) error {
+	err := gitRepo.repository.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash))
+	if err != nil {
+		return fmt.Errorf("failed to detach HEAD: %v", err)
+	}
+	return nil
+}
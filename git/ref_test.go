@@ -0,0 +1,158 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"flag"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// tag создает тег на коммите. Аннотированный тег создается, если задано сообщение.
+func (r *remoteRepo) tag(name string, hash plumbing.Hash, message string) {
+
+	var opts *gogit.CreateTagOptions
+	if message != "" {
+		opts = &gogit.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "Test", Email: "test@example.com"},
+			Message: message,
+		}
+	}
+
+	if _, err := r.repository.CreateTag(name, hash, opts); err != nil {
+		r.t.Fatalf("Error creating tag %s: %v", name, err)
+	}
+}
+
+// newRefTestFlags создает набор флагов без ветки с флагами выбора тега и коммита
+func newRefTestFlags(t *testing.T, remote *remoteRepo, localPath string) *flag.FlagSet {
+
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Set(constants.FlagRepoBranch, "")
+	mockFlags.String(constants.FlagRepoTag, "", "Repository tag")
+	mockFlags.String(constants.FlagRepoTagConstraint, "", "Repository tag constraint")
+	mockFlags.String(constants.FlagRepoCommit, "", "Repository commit")
+
+	return mockFlags
+}
+
+func TestTrackLatestTag(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	v100 := remote.commit("release 1.0.0", map[string]string{"app.txt": "1.0.0"})
+	remote.tag("v1.0.0", v100, "")
+	v110 := remote.commit("release 1.1.0", map[string]string{"app.txt": "1.1.0"})
+	remote.tag("v1.1.0", v110, "release 1.1.0")
+	v200 := remote.commit("release 2.0.0", map[string]string{"app.txt": "2.0.0"})
+	remote.tag("v2.0.0", v200, "")
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	gitRepo := newTestRepository(t, newRefTestFlags(t, remote, localPath),
+		"--"+constants.FlagRepoTagConstraint+"=>=1.0.0, <2.0.0")
+
+	// Выбран самый новый тег в диапазоне (аннотированный)
+	if gitRepo.CommitHash() != v110.String() {
+		t.Fatalf("Expected commit %s of v1.1.0, got %s", v110, gitRepo.CommitHash())
+	}
+	content, _ := os.ReadFile(filepath.Join(localPath, "app.txt"))
+	if string(content) != "1.1.0" {
+		t.Errorf("Expected app.txt of v1.1.0, got %q", content)
+	}
+
+	// Новые коммиты без тегов и prerelease-теги не меняют состояние
+	rc := remote.commit("release candidate", map[string]string{"app.txt": "1.2.0-rc.1"})
+	remote.tag("v1.2.0-rc.1", rc, "")
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() || gitRepo.CommitHash() != v110.String() {
+		t.Errorf("Expected to stay on v1.1.0, got %s", gitRepo.CommitHash())
+	}
+
+	// Новый подходящий тег
+	v120 := remote.commit("release 1.2.0", map[string]string{"app.txt": "1.2.0"})
+	remote.tag("v1.2.0", v120, "")
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if !gitRepo.HasChanges() || gitRepo.CommitHash() != v120.String() {
+		t.Errorf("Expected to move to v1.2.0, got %s", gitRepo.CommitHash())
+	}
+	if commit, err := gitRepo.Commit(); err != nil || commit.Tag != "v1.2.0" {
+		t.Errorf("Expected tag v1.2.0, got %+v (%v)", commit, err)
+	}
+
+	// Новый тег на том же коммите отражается в информации о коммите
+	remote.tag("v1.3.0", v120, "")
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if commit, err := gitRepo.Commit(); err != nil || commit.Tag != "v1.3.0" || commit.Hash != v120.String() {
+		t.Errorf("Expected tag v1.3.0 on %s, got %+v (%v)", v120, commit, err)
+	}
+
+	// Удаление тега не откатывает синхронизацию назад
+	if err := remote.repository.DeleteTag("v1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != v120.String() {
+		t.Errorf("Expected to stay on v1.2.0, got %s", gitRepo.CommitHash())
+	}
+}
+
+func TestTrackTagAndCommit(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	first := remote.commit("first", map[string]string{"app.txt": "first"})
+	remote.tag("stable", first, "")
+	remote.commit("second", map[string]string{"app.txt": "second"})
+
+	// Фиксированный тег
+	localPath := filepath.Join(t.TempDir(), "tag")
+	gitRepo := newTestRepository(t, newRefTestFlags(t, remote, localPath), "--"+constants.FlagRepoTag+"=stable")
+	if gitRepo.CommitHash() != first.String() {
+		t.Errorf("Expected commit %s of tag stable, got %s", first, gitRepo.CommitHash())
+	}
+
+	// Коммит в ветке не меняет состояние
+	remote.commit("third", map[string]string{"app.txt": "third"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Error("Expected no changes while tracking a fixed tag")
+	}
+
+	// Фиксированный коммит (сокращенный хеш)
+	localPath = filepath.Join(t.TempDir(), "commit")
+	gitRepo = newTestRepository(t, newRefTestFlags(t, remote, localPath), "--"+constants.FlagRepoCommit+"="+first.String()[:10])
+	if gitRepo.CommitHash() != first.String() {
+		t.Errorf("Expected pinned commit %s, got %s", first, gitRepo.CommitHash())
+	}
+	content, _ := os.ReadFile(filepath.Join(localPath, "app.txt"))
+	if string(content) != "first" {
+		t.Errorf("Expected app.txt of the pinned commit, got %q", content)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version - версия в формате semver (https://semver.org)
+type version struct {
+	major      int
+	minor      int
+	patch      int
+	prerelease string
+}
+
+// parseVersion разбирает версию вида [v]MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD].
+// Отсутствующие MINOR и PATCH считаются равными нулю.
+func parseVersion(s string) (version, bool) {
+
+	var v version
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	// Метаданные сборки не участвуют в сравнении версий
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+		if v.prerelease == "" {
+			return v, false
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		*numbers[i] = n
+	}
+
+	return v, true
+}
+
+// compare сравнивает версии и возвращает -1, 0 или 1
+func (v version) compare(o version) int {
+
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	// Версия без prerelease старше версии с prerelease
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	}
+
+	return comparePrerelease(v.prerelease, o.prerelease)
+}
+
+// comparePrerelease сравнивает идентификаторы prerelease по правилам semver
+func comparePrerelease(a, b string) int {
+
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(ap) < len(bp):
+		return -1
+	case len(ap) > len(bp):
+		return 1
+	}
+	return 0
+}
+
+// comparator - условие сравнения с версией
+type comparator struct {
+	op      string
+	version version
+}
+
+// check проверяет, удовлетворяет ли версия условию
+func (c comparator) check(v version) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// versionConstraint - ограничение версий: группы условий, объединенные через "||",
+// внутри группы условия перечисляются через запятую или пробел и объединяются по "И".
+type versionConstraint struct {
+	groups           [][]comparator
+	allowsPrerelease bool
+}
+
+// parseVersionConstraint разбирает ограничение версий.
+// Поддерживаются операторы =, !=, >, >=, <, <=, ^, ~ и шаблоны вида 1.2.x, 1.*.
+func parseVersionConstraint(s string) (*versionConstraint, error) {
+
+	constraint := &versionConstraint{}
+
+	for _, group := range strings.Split(s, "||") {
+
+		var comparators []comparator
+
+		items := strings.FieldsFunc(group, func(r rune) bool { return r == ',' || r == ' ' })
+		for i := 0; i < len(items); i++ {
+			item := items[i]
+
+			// Оператор, отделенный от версии пробелом (">= 1.2.0")
+			if strings.Trim(item, "=!<>^~") == "" && i+1 < len(items) {
+				i++
+				item += items[i]
+			}
+
+			parsed, err := parseComparator(item)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range parsed {
+				if c.version.prerelease != "" {
+					constraint.allowsPrerelease = true
+				}
+			}
+			comparators = append(comparators, parsed...)
+		}
+
+		if len(comparators) == 0 {
+			return nil, fmt.Errorf("invalid version constraint: %q", s)
+		}
+
+		constraint.groups = append(constraint.groups, comparators)
+	}
+
+	return constraint, nil
+}
+
+// parseComparator разбирает одно условие и приводит операторы ^, ~ и шаблоны к диапазонам
+func parseComparator(s string) ([]comparator, error) {
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, strings.TrimSpace(s[len(prefix):])
+			break
+		}
+	}
+
+	// Шаблоны вида 1.x, 1.2.*, *
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	wildcard := -1
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wildcard = i
+			break
+		}
+	}
+	if wildcard >= 0 {
+		if op != "" && op != "=" {
+			return nil, fmt.Errorf("invalid version constraint: %q", op+s)
+		}
+		if wildcard == 0 {
+			return []comparator{{op: ">=", version: version{}}}, nil
+		}
+		lower, ok := parseVersion(strings.Join(parts[:wildcard], "."))
+		if !ok {
+			return nil, fmt.Errorf("invalid version: %q", s)
+		}
+		var upper version
+		if wildcard == 1 {
+			upper = version{major: lower.major + 1}
+		} else {
+			upper = version{major: lower.major, minor: lower.minor + 1}
+		}
+		return []comparator{{op: ">=", version: lower}, {op: "<", version: upper}}, nil
+	}
+
+	v, ok := parseVersion(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid version: %q", s)
+	}
+
+	switch op {
+	case "":
+		return []comparator{{op: "=", version: v}}, nil
+	case "^":
+		// Совместимые изменения: не меняется первая ненулевая компонента
+		upper := version{major: v.major + 1}
+		if v.major == 0 {
+			upper = version{minor: v.minor + 1}
+			if v.minor == 0 {
+				upper = version{patch: v.patch + 1}
+			}
+		}
+		return []comparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "~":
+		// Изменения patch-версии
+		return []comparator{{op: ">=", version: v}, {op: "<", version: version{major: v.major, minor: v.minor + 1}}}, nil
+	}
+
+	return []comparator{{op: op, version: v}}, nil
+}
+
+// check проверяет, удовлетворяет ли версия ограничению.
+// Версии prerelease подходят, только если они явно указаны в ограничении.
+func (c *versionConstraint) check(v version) bool {
+
+	if v.prerelease != "" && !c.allowsPrerelease {
+		return false
+	}
+
+	for _, group := range c.groups {
+		matched := true
+		for _, comparator := range group {
+			if !comparator.check(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected version
+		ok       bool
+	}{
+		{"v1.2.3", version{major: 1, minor: 2, patch: 3}, true},
+		{"1.2", version{major: 1, minor: 2}, true},
+		{"v2", version{major: 2}, true},
+		{"v1.0.0-rc.1+build.5", version{major: 1, prerelease: "rc.1"}, true},
+		{"release", version{}, false},
+		{"v1.2.3.4", version{}, false},
+		{"v1.-2", version{}, false},
+		{"v1.0.0-", version{}, false},
+	}
+
+	for _, tt := range tests {
+		v, ok := parseVersion(tt.input)
+		if ok != tt.ok {
+			t.Errorf("parseVersion(%q): expected ok=%v, got %v", tt.input, tt.ok, ok)
+			continue
+		}
+		if ok && v != tt.expected {
+			t.Errorf("parseVersion(%q): expected %+v, got %+v", tt.input, tt.expected, v)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+
+	// Версии в порядке возрастания
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, _ := parseVersion(ordered[i])
+		b, _ := parseVersion(ordered[i+1])
+		if a.compare(b) != -1 || b.compare(a) != 1 {
+			t.Errorf("Expected %s < %s", ordered[i], ordered[i+1])
+		}
+		if a.compare(a) != 0 {
+			t.Errorf("Expected %s == %s", ordered[i], ordered[i])
+		}
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{">=1.2.0, <2.0.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "1.5.0-rc.1"}},
+		{">= 1.2 < 2", []string{"1.2.0", "1.9.0"}, []string{"2.0.0"}},
+		{"^1.4", []string{"1.4.0", "1.99.0"}, []string{"1.3.9", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.9"}, []string{"1.5.0", "1.4.1"}},
+		{"1.x", []string{"1.0.0", "1.9.9"}, []string{"0.9.0", "2.0.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"*", []string{"0.0.1", "9.0.0"}, []string{"1.0.0-beta"}},
+		{"1.0.0 || >=3.0.0", []string{"1.0.0", "3.1.0"}, []string{"2.0.0"}},
+		{"!=1.0.1", []string{"1.0.0", "1.0.2"}, []string{"1.0.1"}},
+		{">=2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0"}, []string{"2.0.0-beta.1"}},
+	}
+
+	for _, tt := range tests {
+		c, err := parseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("parseVersionConstraint(%q): unexpected error: %v", tt.constraint, err)
+			continue
+		}
+		for _, s := range tt.matches {
+			v, _ := parseVersion(s)
+			if !c.check(v) {
+				t.Errorf("Expected %s to match %q", s, tt.constraint)
+			}
+		}
+		for _, s := range tt.rejects {
+			v, _ := parseVersion(s)
+			if c.check(v) {
+				t.Errorf("Expected %s not to match %q", s, tt.constraint)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"", ">=", "abc", ">1.x", "1.0 ||"} {
+		if _, err := parseVersionConstraint(invalid); err == nil {
+			t.Errorf("parseVersionConstraint(%q): expected an error", invalid)
+		}
+	}
+}
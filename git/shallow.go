@@ -0,0 +1,94 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/logger"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// fullHistoryDepth глубина, при которой сервер возвращает всю историю (аналог git fetch --unshallow)
+	fullHistoryDepth int = 2147483647
+
+	// maxDeepenAttempts количество попыток углубления истории, после которых загружается вся история
+	maxDeepenAttempts int = 8
+)
+
+// isShallow проверяет, используется ли неполная история
+func (gitRepo *GitRepository) isShallow() bool {
+	return gitRepo.depth > 0 && gitRepo.depth < fullHistoryDepth
+}
+
+// deepenRepo увеличивает глубину истории локального репозитория вдвое.
+// После maxDeepenAttempts попыток загружается вся история. Время выполнения ограничено fetchTimeout.
+func (gitRepo *GitRepository) deepenRepo(ctx context.Context, attempt int) error {
+
+	if attempt >= maxDeepenAttempts {
+		gitRepo.depth = fullHistoryDepth
+	} else {
+		gitRepo.depth *= 2
+	}
+
+	logger.GetLogger().Info("Deepening repository history (depth: %d)\n", gitRepo.depth)
+
+	remote, err := gitRepo.repository.Remote(gitRepo.options.originName)
+	if err != nil {
+		return fmt.Errorf("failed to get remote: %v", err)
+	}
+
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		return err
+	}
+
+	return withTimeout(ctx, gitRepo.options.fetchTimeout, ErrFetchTimeout, func(ctx context.Context) error {
+		err := remote.FetchContext(ctx, &git.FetchOptions{
+			Auth:  auth,
+			Depth: gitRepo.depth,
+			Force: true,
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return fmt.Errorf("failed to deepen repository history: %w", err)
+		}
+		return nil
+	})
+}
+
+// withHistory выполняет операцию op. Если операции не хватает объектов
+// из-за неполной истории, история углубляется и операция повторяется.
+func (gitRepo *GitRepository) withHistory(ctx context.Context, op func() error) error {
+
+	for attempt := 1; ; attempt++ {
+
+		err := op()
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, plumbing.ErrObjectNotFound) || !gitRepo.options.deepen || !gitRepo.isShallow() {
+			return err
+		}
+
+		if err := gitRepo.deepenRepo(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
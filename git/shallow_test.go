@@ -0,0 +1,65 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/internal/constants"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+func TestShallowClone(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("second commit", map[string]string{"a.txt": "a"})
+	remote.commit("third commit", map[string]string{"b.txt": "b"})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Int(constants.FlagRepoDepth, 0, "Repository depth")
+
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagRepoDepth+"=1")
+
+	// Проверяем, что локальный репозиторий содержит неполную историю
+	repository, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		t.Fatalf("Error opening local repository: %v", err)
+	}
+	shallows, err := repository.Storer.Shallow()
+	if err != nil {
+		t.Fatalf("Error reading shallow commits: %v", err)
+	}
+	if len(shallows) == 0 {
+		t.Error("Expected shallow repository, but got full history")
+	}
+
+	// Новые коммиты удаленного репозитория принимаются при синхронизации
+	hash := remote.commit("fourth commit", map[string]string{"c.txt": "c"})
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+
+	if gitRepo.CommitHash() != hash.String() {
+		t.Errorf("Expected commit %s, got %s", hash, gitRepo.CommitHash())
+	}
+
+	if !gitRepo.HasChanges() {
+		t.Error("Expected changes after sync")
+	}
+}
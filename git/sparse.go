@@ -0,0 +1,200 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// isSparse проверяет, включен ли режим частичного checkout
+func (gitRepo *GitRepository) isSparse() bool {
+	return len(gitRepo.options.sparsePaths) > 0
+}
+
+// inSparse проверяет, входит ли путь в набор частичного checkout.
+// Если режим частичного checkout не включен, в набор входят все пути.
+func (gitRepo *GitRepository) inSparse(name string) bool {
+	if !gitRepo.isSparse() {
+		return true
+	}
+	_, ok := matchAnyPath(gitRepo.options.sparsePaths, name)
+	return ok
+}
+
+// checkoutSparse переключает локальный репозиторий на коммит commit.
+// Индекс обновляется полностью, а в рабочем каталоге создаются, изменяются или удаляются
+// только файлы из набора частичного checkout. Если changes равен nil, в рабочий каталог
+// записываются все подходящие файлы коммита.
+func (gitRepo *GitRepository) checkoutSparse(ctx context.Context, commit *object.Commit, changes object.Changes) error {
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get tree: %v", err)
+	}
+
+	// Переключаем ветку и индекс, рабочий каталог не изменяется
+	err = wt.Reset(&git.ResetOptions{
+		Commit: commit.Hash,
+		Mode:   git.MixedReset,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset index: %v", err)
+	}
+
+	var names []string
+	if changes == nil {
+		err = tree.Files().ForEach(func(f *object.File) error {
+			names = append(names, f.Name)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list files: %v", err)
+		}
+	} else {
+		for _, change := range changes {
+			names = append(names, change.From.Name, change.To.Name)
+		}
+	}
+
+	return gitRepo.restoreSparse(ctx, tree, names)
+}
+
+// resetSparse отменяет локальные изменения файлов names в режиме частичного checkout
+func (gitRepo *GitRepository) resetSparse(ctx context.Context, names []string) error {
+
+	commit, err := gitRepo.getCommit(false)
+	if err != nil {
+		return err
+	}
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get tree: %v", err)
+	}
+
+	// Сбрасываем индекс, рабочий каталог не изменяется
+	err = wt.Reset(&git.ResetOptions{
+		Commit: commit.Hash,
+		Mode:   git.MixedReset,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset changes: %v", err)
+	}
+
+	return gitRepo.restoreSparse(ctx, tree, names)
+}
+
+// restoreSparse приводит файлы names рабочего каталога к состоянию дерева tree.
+// Файлы вне набора частичного checkout пропускаются, отсутствующие в дереве - удаляются.
+// При отмене ctx запись прерывается перед очередным файлом.
+func (gitRepo *GitRepository) restoreSparse(ctx context.Context, tree *object.Tree, names []string) error {
+
+	for _, name := range names {
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to checkout files: %w", err)
+		}
+
+		if name == "" || !gitRepo.inSparse(name) {
+			continue
+		}
+
+		dst := filepath.Join(gitRepo.options.path, filepath.FromSlash(name))
+
+		file, err := tree.File(name)
+		if errors.Is(err, object.ErrFileNotFound) {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove file %s: %v", name, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get file %s: %v", name, err)
+		}
+
+		if err := exportFile(file, dst); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportFile записывает содержимое файла из дерева коммита по пути dst
+// с учетом режима доступа (исполняемый файл, символическая ссылка).
+func exportFile(file *object.File, dst string) error {
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", file.Name, err)
+	}
+
+	// Удаляем существующий файл, чтобы корректно заменить символические ссылки
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace file %s: %v", file.Name, err)
+	}
+
+	if file.Mode == filemode.Symlink {
+		target, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("failed to read symlink %s: %v", file.Name, err)
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %v", file.Name, err)
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0644)
+	if file.Mode == filemode.Executable {
+		perm = 0755
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %v", file.Name, err)
+	}
+	defer reader.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", file.Name, err)
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write file %s: %v", file.Name, err)
+	}
+
+	return out.Close()
+}
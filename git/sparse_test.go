@@ -0,0 +1,93 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSparseCheckout(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	remote.commit("add deploy", map[string]string{
+		"deploy/prod/app.yaml":  "prod",
+		"deploy/stage/app.yaml": "stage",
+	})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.String(constants.FlagSparsePaths, "", "Sparse checkout paths")
+
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagSparsePaths+"=deploy/prod")
+
+	// В рабочем каталоге только выбранные пути
+	if _, err := os.Stat(filepath.Join(localPath, "deploy/prod/app.yaml")); err != nil {
+		t.Errorf("Expected deploy/prod/app.yaml to exist: %v", err)
+	}
+	for _, name := range []string{"README.md", "deploy/stage/app.yaml"} {
+		if _, err := os.Stat(filepath.Join(localPath, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be absent from the worktree", name)
+		}
+	}
+
+	// Изменения вне выбранных путей не считаются изменениями
+	hash := remote.commit("update stage", map[string]string{"deploy/stage/app.yaml": "stage v2"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Error("Expected no changes for paths outside the sparse set")
+	}
+	if gitRepo.CommitHash() != hash.String() {
+		t.Errorf("Expected commit %s, got %s", hash, gitRepo.CommitHash())
+	}
+
+	// Изменения в выбранных путях записываются в рабочий каталог
+	remote.commit("update prod", map[string]string{"deploy/prod/app.yaml": "prod v2"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if !gitRepo.HasChanges() {
+		t.Error("Expected changes for paths inside the sparse set")
+	}
+	content, err := os.ReadFile(filepath.Join(localPath, "deploy/prod/app.yaml"))
+	if err != nil || string(content) != "prod v2" {
+		t.Errorf("Expected updated deploy/prod/app.yaml, got %q (%v)", content, err)
+	}
+
+	// Локальные изменения в выбранных путях отменяются
+	if err := os.WriteFile(filepath.Join(localPath, "deploy/prod/app.yaml"), []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(localPath, "deploy/prod/app.yaml"))
+	if string(content) != "prod v2" {
+		t.Errorf("Expected local changes to be reset, got %q", content)
+	}
+
+	// Повторная синхронизация без изменений
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Error("Expected no changes on a clean sparse worktree")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"git-sync/logger"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// SubmoduleInfo содержит информацию о состоянии подмодуля
+type SubmoduleInfo struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// recurseSubmodules возвращает глубину рекурсивной обработки подмодулей
+func (gitRepo *GitRepository) recurseSubmodules() git.SubmoduleRescursivity {
+	if !gitRepo.options.submodules {
+		return git.NoRecurseSubmodules
+	}
+	return git.DefaultSubmoduleRecursionDepth
+}
+
+// syncSubmodules инициализирует и обновляет подмодули до коммитов, записанных в локальном репозитории.
+// Если состояние хотя бы одного подмодуля изменилось, устанавливается флаг "найдены изменения"
+// и возвращается true.
+func (gitRepo *GitRepository) syncSubmodules(ctx context.Context) (bool, error) {
+
+	if !gitRepo.options.submodules {
+		return false, nil
+	}
+
+	before, err := gitRepo.submodulesStatus()
+	if err != nil {
+		return false, err
+	}
+
+	// Все подмодули в актуальном состоянии
+	outdated := false
+	for _, hash := range before {
+		if hash.Current != hash.Expected {
+			outdated = true
+			break
+		}
+	}
+	if !outdated {
+		return false, nil
+	}
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return false, err
+	}
+
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return false, fmt.Errorf("failed to get submodules: %v", err)
+	}
+
+	auth, err := gitRepo.authMethod()
+	if err != nil {
+		return false, err
+	}
+
+	err = submodules.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: gitRepo.recurseSubmodules(),
+		Auth:              auth,
+		Depth:             gitRepo.options.depth,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update submodules: %w", err)
+	}
+
+	after, err := gitRepo.submodulesStatus()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for path, hash := range after {
+		if prev, ok := before[path]; !ok || prev.Current != hash.Current {
+			changed = true
+			logger.GetLogger().Info("submodule %s %s\n", path, hash.Current)
+		}
+	}
+
+	gitRepo.setChangesFlag(changed)
+
+	return changed, nil
+}
+
+// submodulesStatus возвращает состояние подмодулей локального репозитория по их путям
+func (gitRepo *GitRepository) submodulesStatus() (map[string]*git.SubmoduleStatus, error) {
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return nil, err
+	}
+
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodules: %v", err)
+	}
+
+	status, err := submodules.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodules status: %v", err)
+	}
+
+	result := make(map[string]*git.SubmoduleStatus, len(status))
+	for _, s := range status {
+		result[s.Path] = s
+	}
+
+	return result, nil
+}
+
+// submodulesInfo возвращает отсортированный список подмодулей с их текущими коммитами
+func (gitRepo *GitRepository) submodulesInfo() ([]SubmoduleInfo, error) {
+
+	if !gitRepo.options.submodules {
+		return nil, nil
+	}
+
+	status, err := gitRepo.submodulesStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	var info []SubmoduleInfo
+	for path, s := range status {
+		info = append(info, SubmoduleInfo{Path: path, Hash: s.Current.String()})
+	}
+
+	sort.Slice(info, func(i, j int) bool {
+		return info[i].Path < info[j].Path
+	})
+
+	return info, nil
+}
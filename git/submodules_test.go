@@ -0,0 +1,81 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubmodules(t *testing.T) {
+
+	sub := newRemoteRepo(t)
+	subHash := sub.commit("sub commit", map[string]string{"lib.txt": "lib v1"})
+
+	remote := newRemoteRepo(t)
+	remote.commitSubmodule("add submodule", "lib", sub, subHash)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagSubmodules, false, "Sync submodules")
+
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagSubmodules)
+
+	// Подмодуль инициализирован при клонировании
+	content, err := os.ReadFile(filepath.Join(localPath, "lib", "lib.txt"))
+	if err != nil || string(content) != "lib v1" {
+		t.Fatalf("Expected submodule file content 'lib v1', got %q (%v)", content, err)
+	}
+
+	commit, err := gitRepo.Commit()
+	if err != nil {
+		t.Fatalf("Error getting current commit: %v", err)
+	}
+	if len(commit.Submodules) != 1 || commit.Submodules[0].Path != "lib" || commit.Submodules[0].Hash != subHash.String() {
+		t.Errorf("Unexpected submodules info: %+v", commit.Submodules)
+	}
+
+	// Обновление подмодуля в удаленном репозитории
+	subHash = sub.commit("sub commit 2", map[string]string{"lib.txt": "lib v2"})
+	remote.commitSubmodule("update submodule", "lib", sub, subHash)
+
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if !gitRepo.HasChanges() {
+		t.Error("Expected changes after submodule update")
+	}
+
+	content, _ = os.ReadFile(filepath.Join(localPath, "lib", "lib.txt"))
+	if string(content) != "lib v2" {
+		t.Errorf("Expected updated submodule file content 'lib v2', got %q", content)
+	}
+
+	commit, _ = gitRepo.Commit()
+	if len(commit.Submodules) != 1 || commit.Submodules[0].Hash != subHash.String() {
+		t.Errorf("Unexpected submodules info after update: %+v", commit.Submodules)
+	}
+
+	// Повторная синхронизация без изменений
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Error("Expected no changes on up-to-date submodules")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ошибки превышения времени выполнения операций
+var (
+	ErrCloneTimeout    = errors.New("clone timed out")
+	ErrFetchTimeout    = errors.New("fetch timed out")
+	ErrCheckoutTimeout = errors.New("checkout timed out")
+	ErrPushTimeout     = errors.New("push timed out")
+)
+
+// withTimeout выполняет операцию op с контекстом, ограниченным временем timeout (0 - без ограничения).
+// Если время истекло, а родительский контекст не отменен, ошибка операции оборачивается в timeoutErr.
+func withTimeout(ctx context.Context, timeout time.Duration, timeoutErr error, op func(ctx context.Context) error) error {
+
+	if timeout <= 0 {
+		return op(ctx)
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := op(opCtx)
+	if err != nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("%w after %s: %w", timeoutErr, timeout, err)
+	}
+
+	return err
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCloneTimeout(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Duration(constants.FlagCloneTimeout, 0, "Clone timeout")
+	if err := mockFlags.Parse([]string{"--" + constants.FlagCloneTimeout + "=1ns"}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+
+	_, err := git.NewGitRepository(mockFlags, context.Background())
+	if !errors.Is(err, git.ErrCloneTimeout) {
+		t.Fatalf("Expected clone timeout, got %v", err)
+	}
+	if errors.Is(err, git.ErrFetchTimeout) || errors.Is(err, git.ErrCheckoutTimeout) {
+		t.Errorf("Unexpected timeout kind: %v", err)
+	}
+
+	// Частично клонированный репозиторий не остается на диске
+	if _, err := os.Stat(filepath.Join(localPath, ".git")); !os.IsNotExist(err) {
+		t.Errorf("Expected no repository after clone timeout, got %v", err)
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Duration(constants.FlagFetchTimeout, 0, "Fetch timeout")
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagFetchTimeout+"=1ns")
+
+	remote.commit("next", map[string]string{"app.txt": "next"})
+
+	err := gitRepo.Sync(context.Background())
+	if !errors.Is(err, git.ErrFetchTimeout) {
+		t.Fatalf("Expected fetch timeout, got %v", err)
+	}
+	if gitRepo.HasChanges() {
+		t.Errorf("Expected no changes after fetch timeout")
+	}
+}
+
+func TestSyncCanceled(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Duration(constants.FlagFetchTimeout, 0, "Fetch timeout")
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagFetchTimeout+"=1m")
+
+	initial := gitRepo.CommitHash()
+	remote.commit("next", map[string]string{"app.txt": "next"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Отмена контекста не считается превышением времени операции
+	err := gitRepo.Sync(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected canceled sync, got %v", err)
+	}
+	if errors.Is(err, git.ErrFetchTimeout) {
+		t.Errorf("Unexpected fetch timeout: %v", err)
+	}
+
+	// После отмены синхронизация продолжается с новым контекстом
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := gitRepo.Sync(ctx); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() == initial {
+		t.Errorf("Expected new commit after sync")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// isHTTP проверяет, используется ли для удаленного репозитория протокол HTTP(S)
+func (gitRepo *GitRepository) isHTTP() bool {
+	endpoint, err := transport.NewEndpoint(gitRepo.options.url)
+	if err != nil {
+		return false
+	}
+	return endpoint.Protocol == "http" || endpoint.Protocol == "https"
+}
+
+// installTransport устанавливает HTTP-клиент для операций с удаленным репозиторием
+// (clone, fetch, pull, подмодули) с учетом настроек TLS и прокси.
+// Клиент go-git регистрируется глобально для протоколов http и https.
+func (gitRepo *GitRepository) installTransport() error {
+
+	if !gitRepo.isHTTP() {
+		return nil
+	}
+
+	httpTransport, err := gitRepo.httpTransport()
+	if err != nil {
+		return err
+	}
+
+	httpClient := githttp.NewClient(&http.Client{Transport: httpTransport})
+	client.InstallProtocol("http", httpClient)
+	client.InstallProtocol("https", httpClient)
+
+	return nil
+}
+
+// httpTransport создает HTTP-транспорт с дополнительными корневыми сертификатами (caFile),
+// клиентским сертификатом для mTLS (clientCert, clientKey), отключенной проверкой сертификата
+// сервера (insecureSkipVerify) и прокси-сервером.
+func (gitRepo *GitRepository) httpTransport() (*http.Transport, error) {
+
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if gitRepo.options.caFile != "" {
+		bundle, err := os.ReadFile(gitRepo.options.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", gitRepo.options.caFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if gitRepo.options.clientCert != "" || gitRepo.options.clientKey != "" {
+		cert, err := tls.LoadX509KeyPair(gitRepo.options.clientCert, gitRepo.options.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if gitRepo.options.insecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	httpTransport.TLSClientConfig = tlsConfig
+	httpTransport.Proxy = gitRepo.proxyFunc()
+
+	return httpTransport, nil
+}
+
+// proxyFunc возвращает функцию выбора прокси-сервера для запроса.
+// Настройки читаются из переменных окружения HTTPS_PROXY, HTTP_PROXY и NO_PROXY при каждом запросе.
+// Явно заданный прокси-сервер (proxy) заменяет HTTPS_PROXY и HTTP_PROXY, а noProxy - NO_PROXY.
+func (gitRepo *GitRepository) proxyFunc() func(*http.Request) (*url.URL, error) {
+
+	return func(req *http.Request) (*url.URL, error) {
+		config := httpproxy.FromEnvironment()
+		if gitRepo.options.proxy != "" {
+			config.HTTPProxy = gitRepo.options.proxy
+			config.HTTPSProxy = gitRepo.options.proxy
+		}
+		if gitRepo.options.noProxy != "" {
+			config.NoProxy = gitRepo.options.noProxy
+		}
+		return config.ProxyFunc()(req.URL)
+	}
+}
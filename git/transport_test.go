@@ -0,0 +1,216 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM записывает блок PEM в файл каталога dir и возвращает путь к файлу
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Error writing %s: %v", name, err)
+	}
+	return path
+}
+
+// newClientCert создает самоподписанный клиентский сертификат и возвращает его вместе с путями к файлам
+func newClientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "git-sync"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate: %v", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error marshaling key: %v", err)
+	}
+
+	return cert, writePEM(t, dir, "client.crt", "CERTIFICATE", der), writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDer)
+}
+
+// get выполняет запрос к серверу через HTTP-транспорт репозитория
+func get(t *testing.T, gitRepo *GitRepository, url string) error {
+
+	httpTransport, err := gitRepo.httpTransport()
+	if err != nil {
+		t.Fatalf("Error creating transport: %v", err)
+	}
+
+	resp, err := (&http.Client{Transport: httpTransport}).Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func TestHTTPTransportTLS(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	// Сертификат сервера не входит в системные корневые сертификаты
+	gitRepo := &GitRepository{options: &GitRepositoryOptions{}}
+	if err := get(t, gitRepo, server.URL); err == nil {
+		t.Errorf("Expected certificate error without CA bundle")
+	}
+
+	gitRepo.options.caFile = caFile
+	if err := get(t, gitRepo, server.URL); err != nil {
+		t.Errorf("Expected request with CA bundle to succeed: %v", err)
+	}
+
+	gitRepo.options = &GitRepositoryOptions{insecureSkipVerify: true}
+	if err := get(t, gitRepo, server.URL); err != nil {
+		t.Errorf("Expected request without verification to succeed: %v", err)
+	}
+}
+
+func TestHTTPTransportClientCert(t *testing.T) {
+
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := newClientCert(t, dir)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	gitRepo := &GitRepository{options: &GitRepositoryOptions{caFile: caFile}}
+	if err := get(t, gitRepo, server.URL); err == nil {
+		t.Errorf("Expected error without client certificate")
+	}
+
+	gitRepo.options.clientCert = certFile
+	gitRepo.options.clientKey = keyFile
+	if err := get(t, gitRepo, server.URL); err != nil {
+		t.Errorf("Expected request with client certificate to succeed: %v", err)
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+
+	gitRepo := &GitRepository{options: &GitRepositoryOptions{
+		proxy:   "http://proxy.example.com:3128",
+		noProxy: "internal.example.com,.local",
+	}}
+	proxy := gitRepo.proxyFunc()
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://git.example.com/repo.git", "http://proxy.example.com:3128"},
+		{"http://git.example.com/repo.git", "http://proxy.example.com:3128"},
+		{"https://internal.example.com/repo.git", ""},
+		{"https://git.local/repo.git", ""},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatalf("Error selecting proxy for %s: %v", tt.url, err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.expected {
+			t.Errorf("Expected proxy %q for %s, got %q", tt.expected, tt.url, got)
+		}
+	}
+
+	// Без явного прокси-сервера используются переменные окружения
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:8080")
+	t.Setenv("NO_PROXY", "skip.example.com")
+	gitRepo.options.proxy = ""
+	gitRepo.options.noProxy = ""
+	proxy = gitRepo.proxyFunc()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://git.example.com/repo.git", nil)
+	if u, err := proxy(req); err != nil || u == nil || u.Host != "env-proxy.example.com:8080" {
+		t.Errorf("Expected proxy from HTTPS_PROXY, got %v (%v)", u, err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://skip.example.com/repo.git", nil)
+	if u, err := proxy(req); err != nil || u != nil {
+		t.Errorf("Expected no proxy for NO_PROXY host, got %v (%v)", u, err)
+	}
+}
+
+func TestProxyFuncNoProxyWithEnvProxy(t *testing.T) {
+
+	// Прокси-сервер задан только переменной окружения, исключения - флагом
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:8080")
+	t.Setenv("NO_PROXY", "")
+
+	gitRepo := &GitRepository{options: &GitRepositoryOptions{
+		noProxy: "internal.example.com",
+	}}
+	proxy := gitRepo.proxyFunc()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://internal.example.com/repo.git", nil)
+	if u, err := proxy(req); err != nil || u != nil {
+		t.Errorf("Expected no proxy for %s, got %v (%v)", req.URL, u, err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://git.example.com/repo.git", nil)
+	if u, err := proxy(req); err != nil || u == nil || u.Host != "env-proxy.example.com:8080" {
+		t.Errorf("Expected proxy from HTTPS_PROXY, got %v (%v)", u, err)
+	}
+}
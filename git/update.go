@@ -0,0 +1,176 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git-sync/internal/constants"
+	"git-sync/logger"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Виды обновления отслеживаемой ссылки
+const (
+	UpdateFastForward string = "fast-forward" // новый коммит - потомок текущего
+	UpdateRewrite     string = "rewrite"      // история переписана (force-push)
+	UpdateRollback    string = "rollback"     // откат к предку текущего коммита
+)
+
+// ErrUpdateRefused возвращается, если политика запрещает обновление данного вида
+var ErrUpdateRefused = errors.New("update refused")
+
+// ErrDiverged возвращается в режиме fast-forward-only, если новый коммит
+// не является потомком текущего. Ошибка также соответствует ErrUpdateRefused.
+var ErrDiverged = fmt.Errorf("%w: diverged from the current commit", ErrUpdateRefused)
+
+// UpdateInfo содержит информацию об обновлении отслеживаемой ссылки
+type UpdateInfo struct {
+	Kind   string    `json:"kind"`   // Вид обновления (fast-forward, rewrite, rollback)
+	Action string    `json:"action"` // Примененная политика (follow, refuse, alert)
+	From   string    `json:"from"`   // Текущий коммит
+	To     string    `json:"to"`     // Новый коммит
+	Time   time.Time `json:"time"`
+}
+
+// classifyUpdate определяет вид обновления с коммита local на коммит remote.
+// При неполной истории история углубляется, пока не будет найден общий предок.
+func (gitRepo *GitRepository) classifyUpdate(ctx context.Context, local, remote *object.Commit) (string, error) {
+
+	kind := UpdateRewrite
+
+	err := gitRepo.withHistory(ctx, func() error {
+
+		ok, err := local.IsAncestor(remote)
+		if err != nil {
+			return fmt.Errorf("failed to check ancestry: %w", err)
+		}
+		if ok {
+			kind = UpdateFastForward
+			return nil
+		}
+
+		ok, err = remote.IsAncestor(local)
+		if err != nil {
+			return fmt.Errorf("failed to check ancestry: %w", err)
+		}
+		if ok {
+			kind = UpdateRollback
+		}
+
+		return nil
+	})
+
+	return kind, err
+}
+
+// updatePolicy возвращает политику для вида обновления
+func (gitRepo *GitRepository) updatePolicy(kind string) string {
+
+	var policy string
+	switch kind {
+	case UpdateFastForward:
+		policy = gitRepo.options.onFastForward
+	case UpdateRewrite:
+		policy = gitRepo.options.onRewrite
+	case UpdateRollback:
+		policy = gitRepo.options.onRollback
+	}
+
+	// В режиме fast-forward-only применяются только обновления fast-forward
+	if gitRepo.options.ffOnly && kind != UpdateFastForward {
+		return constants.UpdateRefuse
+	}
+
+	if policy == "" {
+		return constants.UpdateFollow
+	}
+	return policy
+}
+
+// checkUpdate классифицирует обновление, сохраняет информацию о нем (LastUpdate) и применяет политику.
+// Возвращает ErrUpdateRefused, если обновление данного вида запрещено.
+func (gitRepo *GitRepository) checkUpdate(ctx context.Context, local, remote *object.Commit) error {
+
+	kind, err := gitRepo.classifyUpdate(ctx, local, remote)
+	if err != nil {
+		return err
+	}
+
+	update := &UpdateInfo{
+		Kind:   kind,
+		Action: gitRepo.updatePolicy(kind),
+		From:   local.Hash.String(),
+		To:     remote.Hash.String(),
+		Time:   time.Now(),
+	}
+
+	gitRepo.mutex.Lock()
+	gitRepo.lastUpdate = update
+	gitRepo.mutex.Unlock()
+
+	switch update.Action {
+	case constants.UpdateRefuse:
+		logger.GetLogger().Error("update %s %s..%s refused\n", kind, update.From, update.To)
+		if gitRepo.options.ffOnly && kind != UpdateFastForward {
+			return fmt.Errorf("%w: %s %s..%s", ErrDiverged, kind, update.From, update.To)
+		}
+		return fmt.Errorf("%w: %s %s..%s", ErrUpdateRefused, kind, update.From, update.To)
+	case constants.UpdateAlert:
+		logger.GetLogger().Warning("update %s %s..%s\n", kind, update.From, update.To)
+	default:
+		logger.GetLogger().Info("update %s %s..%s\n", kind, update.From, update.To)
+	}
+
+	return nil
+}
+
+// resetBranch переключает текущую ветку и рабочий каталог на коммит commit (git reset --hard).
+// Используется для обновлений, которые не являются fast-forward.
+func (gitRepo *GitRepository) resetBranch(commit *object.Commit) error {
+
+	wt, err := gitRepo.getRepoWorktree()
+	if err != nil {
+		return err
+	}
+
+	err = wt.Reset(&git.ResetOptions{
+		Commit: commit.Hash,
+		Mode:   git.HardReset,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset to %s: %v", commit.Hash, err)
+	}
+
+	return nil
+}
+
+// resetLastUpdate сбрасывает информацию об обновлении перед синхронизацией
+func (gitRepo *GitRepository) resetLastUpdate() {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	gitRepo.lastUpdate = nil
+}
+
+// LastUpdate возвращает информацию об обновлении, найденном при последней синхронизации
+func (gitRepo *GitRepository) LastUpdate() *UpdateInfo {
+	gitRepo.mutex.Lock()
+	defer gitRepo.mutex.Unlock()
+	return gitRepo.lastUpdate
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"git-sync/git"
+	"git-sync/internal/constants"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateKinds(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	base := remote.commit("base", map[string]string{"app.txt": "base"})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.String(constants.FlagUpdateFastForward, constants.UpdateFollow, "Fast-forward policy")
+	mockFlags.String(constants.FlagUpdateRewrite, constants.UpdateFollow, "Rewrite policy")
+	mockFlags.String(constants.FlagUpdateRollback, constants.UpdateFollow, "Rollback policy")
+
+	gitRepo := newTestRepository(t, mockFlags,
+		"--"+constants.FlagUpdateRewrite+"="+constants.UpdateAlert,
+		"--"+constants.FlagUpdateRollback+"="+constants.UpdateRefuse)
+
+	sync := func(expectedKind, expectedAction string) error {
+		t.Helper()
+		err := gitRepo.Sync(context.Background())
+		update := gitRepo.LastUpdate()
+		if update == nil {
+			t.Fatalf("Expected %s update, got none (%v)", expectedKind, err)
+		}
+		if update.Kind != expectedKind || update.Action != expectedAction {
+			t.Errorf("Expected %s/%s update, got %s/%s", expectedKind, expectedAction, update.Kind, update.Action)
+		}
+		return err
+	}
+
+	readApp := func() string {
+		content, _ := os.ReadFile(filepath.Join(localPath, "app.txt"))
+		return string(content)
+	}
+
+	// Fast-forward
+	next := remote.commit("next", map[string]string{"app.txt": "next"})
+	if err := sync(git.UpdateFastForward, constants.UpdateFollow); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != next.String() {
+		t.Errorf("Expected commit %s, got %s", next, gitRepo.CommitHash())
+	}
+
+	// Переписанная история (force-push): обновление применяется с предупреждением
+	remote.resetTo(base)
+	rewritten := remote.commit("rewritten", map[string]string{"app.txt": "rewritten"})
+	if err := sync(git.UpdateRewrite, constants.UpdateAlert); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != rewritten.String() || readApp() != "rewritten" {
+		t.Errorf("Expected rewritten commit %s, got %s (%q)", rewritten, gitRepo.CommitHash(), readApp())
+	}
+
+	// Откат к предку: обновление запрещено, локальный репозиторий не изменяется
+	remote.resetTo(base)
+	err := sync(git.UpdateRollback, constants.UpdateRefuse)
+	if !errors.Is(err, git.ErrUpdateRefused) {
+		t.Fatalf("Expected ErrUpdateRefused, got %v", err)
+	}
+	if gitRepo.CommitHash() != rewritten.String() || readApp() != "rewritten" {
+		t.Errorf("Expected to stay on %s, got %s (%q)", rewritten, gitRepo.CommitHash(), readApp())
+	}
+
+	// Без изменений обновление не фиксируется
+	remote.resetTo(rewritten)
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if update := gitRepo.LastUpdate(); update != nil {
+		t.Errorf("Expected no update, got %+v", update)
+	}
+}
+
+func TestFastForwardOnly(t *testing.T) {
+
+	remote := newRemoteRepo(t)
+	base := remote.commit("base", map[string]string{"app.txt": "base"})
+
+	localPath := filepath.Join(t.TempDir(), "repo")
+	mockFlags := newTestFlags(t, remote, localPath)
+	mockFlags.Bool(constants.FlagFFOnly, false, "Fast-forward only")
+
+	gitRepo := newTestRepository(t, mockFlags, "--"+constants.FlagFFOnly)
+
+	// Fast-forward применяется
+	next := remote.commit("next", map[string]string{"app.txt": "next"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != next.String() {
+		t.Errorf("Expected commit %s, got %s", next, gitRepo.CommitHash())
+	}
+
+	// Переписанная история не применяется
+	remote.resetTo(base)
+	remote.commit("rewritten", map[string]string{"app.txt": "rewritten"})
+
+	err := gitRepo.Sync(context.Background())
+	if !errors.Is(err, git.ErrDiverged) || !errors.Is(err, git.ErrUpdateRefused) {
+		t.Fatalf("Expected ErrDiverged, got %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(localPath, "app.txt"))
+	if gitRepo.CommitHash() != next.String() || string(content) != "next" {
+		t.Errorf("Expected to stay on %s, got %s (%q)", next, gitRepo.CommitHash(), content)
+	}
+
+	// Коммит поверх текущего снова применяется
+	remote.resetTo(next)
+	last := remote.commit("last", map[string]string{"app.txt": "last"})
+	if err := gitRepo.Sync(context.Background()); err != nil {
+		t.Fatalf("Error syncing repository: %v", err)
+	}
+	if gitRepo.CommitHash() != last.String() {
+		t.Errorf("Expected commit %s, got %s", last, gitRepo.CommitHash())
+	}
+}
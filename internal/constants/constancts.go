@@ -17,26 +17,211 @@ package constants
 const (
 
 	// Имена флагов
-	FlagRepoUrl                string = "repo-url"
-	FlagRepoBranch             string = "repo-branch"
-	FlagRepoAuthUser           string = "repo-user"
-	FlagRepoAuthToken          string = "repo-token"
-	FlagLocalPath              string = "local-path"
-	FlagSyncInterval           string = "sync-interval"    // 30 секунд
-	FlagHttpServerAddr         string = "http-server-addr" // "0.0.0.0:8080"
-	FlagHttpServerAuthUsername string = "http-auth-username"
-	FlagHttpServerAuthPassword string = "http-auth-password"
-	FlagHttpServerAuthToken    string = "http-auth-token"
+	FlagRepoUrl                  string = "repo-url"
+	FlagRepoBranch               string = "repo-branch"
+	FlagRepoTag                  string = "repo-tag"
+	FlagRepoTagConstraint        string = "repo-tag-constraint"
+	FlagRepoCommit               string = "repo-commit"
+	FlagRepoAuthUser             string = "repo-user"
+	FlagRepoAuthToken            string = "repo-token"
+	FlagRepoAuthUserFile         string = "repo-user-file"
+	FlagRepoAuthTokenFile        string = "repo-token-file"
+	FlagRepoNetrc                string = "repo-netrc"
+	FlagRepoCredentialHelper     string = "repo-credential-helper"
+	FlagRepoAuthMode             string = "repo-auth"
+	FlagRepoGitHubAppID          string = "repo-github-app-id"
+	FlagRepoGitHubInstallationID string = "repo-github-installation-id"
+	FlagRepoGitHubAppKeyFile     string = "repo-github-app-key-file"
+	FlagRepoGitHubAPIURL         string = "repo-github-api-url"
+	FlagRepoDepth                string = "repo-depth"
+	FlagRepoDeepen               string = "repo-deepen"
+	FlagRepoSSHKey               string = "repo-ssh-key"
+	FlagRepoSSHKeyFile           string = "repo-ssh-key-file"
+	FlagRepoSSHKeyPassphrase     string = "repo-ssh-key-passphrase"
+	FlagRepoSSHKeyPassphraseFile string = "repo-ssh-key-passphrase-file"
+	FlagRepoSSHKnownHosts        string = "repo-ssh-known-hosts"
+	FlagRepoSSHStrictHostKey     string = "repo-ssh-strict-host-key"
+	FlagRepoCAFile               string = "repo-ca-file"
+	FlagRepoClientCert           string = "repo-client-cert"
+	FlagRepoClientKey            string = "repo-client-key"
+	FlagRepoInsecureSkipVerify   string = "repo-insecure-skip-verify"
+	FlagAllowCLISecrets          string = "allow-cli-secrets"
+	FlagRepoProxy                string = "repo-proxy"
+	FlagRepoNoProxy              string = "repo-no-proxy"
+	FlagFFOnly                   string = "ff-only"
+	FlagUpdateFastForward        string = "update-fast-forward"
+	FlagUpdateRewrite            string = "update-rewrite"
+	FlagUpdateRollback           string = "update-rollback"
+	FlagLocalPath                string = "local-path"
+	FlagLocalChanges             string = "local-changes"
+	FlagLocalBackupDir           string = "local-backup-dir"
+	FlagClean                    string = "clean"
+	FlagCleanKeepIgnored         string = "clean-keep-ignored"
+	FlagCleanProtected           string = "clean-protected"
+	FlagPush                     string = "push"
+	FlagPushBranch               string = "push-branch"
+	FlagPushStrategy             string = "push-strategy"
+	FlagPushConflict             string = "push-conflict"
+	FlagPushAuthorName           string = "push-author-name"
+	FlagPushAuthorEmail          string = "push-author-email"
+	FlagPushMessage              string = "push-message"
+	FlagDryRun                   string = "dry-run"
+	FlagSparsePaths              string = "sparse-paths"
+	FlagChangesInclude           string = "changes-include"
+	FlagChangesExclude           string = "changes-exclude"
+	FlagCommitDirectives         string = "commit-directives"
+	FlagSubmodules               string = "submodules"
+	FlagPublishLink              string = "publish-link"
+	FlagPublishRoot              string = "publish-root"
+	FlagPublishKeep              string = "publish-keep"
+	FlagSyncInterval             string = "sync-interval" // 30 секунд
+	FlagOneTime                  string = "one-time"
+	FlagHashFile                 string = "hash-file"
+	FlagSyncJitter               string = "sync-jitter"
+	FlagRetryBackoff             string = "retry-backoff"
+	FlagRetryMaxBackoff          string = "retry-max-backoff"
+	FlagCloneTimeout             string = "clone-timeout"
+	FlagFetchTimeout             string = "fetch-timeout"
+	FlagCheckoutTimeout          string = "checkout-timeout"
+	FlagDataDir                  string = "data-dir"
+	FlagHistorySize              string = "history-size"
+	FlagHttpServerAddr           string = "http-server-addr" // "0.0.0.0:8080"
+	FlagHttpServerAuthUsername   string = "http-auth-username"
+	FlagHttpServerAuthPassword   string = "http-auth-password"
+	FlagHttpServerAuthToken      string = "http-auth-token"
 
 	// Имена переменных окружения
-	EnvRepoUrl                string = "GITSYNC_REPOSITORY_URL"
-	EnvRepoBranch             string = "GITSYNC_REPOSITORY_BRANCH"
-	EnvRepoAuthUser           string = "GITSYNC_REPOSITORY_USER"
-	EnvRepoAuthToken          string = "GITSYNC_REPOSITORY_TOKEN"
-	EnvLocalPath              string = "GITSYNC_LOCAL_PATH"
-	EnvSyncInterval           string = "GITSYNC_INTERVAL"
-	EnvHttpServerAddr         string = "GITSYNC_HTTP_SERVER_ADDR"
-	EnvHttpServerAuthUsername string = "GITSYNC_HTTP_AUTH_USERNAME"
-	EnvHttpServerAuthPassword string = "GITSYNC_HTTP_AUTH_PASSWORD"
-	EnvHttpServerAuthToken    string = "GITSYNC_HTTP_AUTH_TOKEN"
+	EnvRepoUrl                  string = "GITSYNC_REPOSITORY_URL"
+	EnvRepoBranch               string = "GITSYNC_REPOSITORY_BRANCH"
+	EnvRepoTag                  string = "GITSYNC_REPOSITORY_TAG"
+	EnvRepoTagConstraint        string = "GITSYNC_REPOSITORY_TAG_CONSTRAINT"
+	EnvRepoCommit               string = "GITSYNC_REPOSITORY_COMMIT"
+	EnvRepoAuthUser             string = "GITSYNC_REPOSITORY_USER"
+	EnvRepoAuthToken            string = "GITSYNC_REPOSITORY_TOKEN"
+	EnvRepoAuthUserFile         string = "GITSYNC_REPOSITORY_USER_FILE"
+	EnvRepoAuthTokenFile        string = "GITSYNC_REPOSITORY_TOKEN_FILE"
+	EnvRepoNetrc                string = "GITSYNC_REPOSITORY_NETRC"
+	EnvRepoCredentialHelper     string = "GITSYNC_REPOSITORY_CREDENTIAL_HELPER"
+	EnvRepoAuthMode             string = "GITSYNC_REPOSITORY_AUTH"
+	EnvRepoGitHubAppID          string = "GITSYNC_REPOSITORY_GITHUB_APP_ID"
+	EnvRepoGitHubInstallationID string = "GITSYNC_REPOSITORY_GITHUB_INSTALLATION_ID"
+	EnvRepoGitHubAppKeyFile     string = "GITSYNC_REPOSITORY_GITHUB_APP_KEY_FILE"
+	EnvRepoGitHubAPIURL         string = "GITSYNC_REPOSITORY_GITHUB_API_URL"
+	EnvRepoDepth                string = "GITSYNC_REPOSITORY_DEPTH"
+	EnvRepoDeepen               string = "GITSYNC_REPOSITORY_DEEPEN"
+	EnvRepoSSHKey               string = "GITSYNC_REPOSITORY_SSH_KEY"
+	EnvRepoSSHKeyFile           string = "GITSYNC_REPOSITORY_SSH_KEY_FILE"
+	EnvRepoSSHKeyPassphrase     string = "GITSYNC_REPOSITORY_SSH_KEY_PASSPHRASE"
+	EnvRepoSSHKeyPassphraseFile string = "GITSYNC_REPOSITORY_SSH_KEY_PASSPHRASE_FILE"
+	EnvRepoSSHKnownHosts        string = "GITSYNC_REPOSITORY_SSH_KNOWN_HOSTS"
+	EnvRepoSSHStrictHostKey     string = "GITSYNC_REPOSITORY_SSH_STRICT_HOST_KEY"
+	EnvRepoCAFile               string = "GITSYNC_REPOSITORY_CA_FILE"
+	EnvRepoClientCert           string = "GITSYNC_REPOSITORY_CLIENT_CERT"
+	EnvRepoClientKey            string = "GITSYNC_REPOSITORY_CLIENT_KEY"
+	EnvRepoInsecureSkipVerify   string = "GITSYNC_REPOSITORY_INSECURE_SKIP_VERIFY"
+	EnvAllowCLISecrets          string = "GITSYNC_ALLOW_CLI_SECRETS"
+	EnvRepoProxy                string = "GITSYNC_REPOSITORY_PROXY"
+	EnvRepoNoProxy              string = "GITSYNC_REPOSITORY_NO_PROXY"
+	EnvFFOnly                   string = "GITSYNC_FF_ONLY"
+	EnvUpdateFastForward        string = "GITSYNC_UPDATE_FAST_FORWARD"
+	EnvUpdateRewrite            string = "GITSYNC_UPDATE_REWRITE"
+	EnvUpdateRollback           string = "GITSYNC_UPDATE_ROLLBACK"
+	EnvLocalPath                string = "GITSYNC_LOCAL_PATH"
+	EnvLocalChanges             string = "GITSYNC_LOCAL_CHANGES"
+	EnvLocalBackupDir           string = "GITSYNC_LOCAL_BACKUP_DIR"
+	EnvClean                    string = "GITSYNC_CLEAN"
+	EnvCleanKeepIgnored         string = "GITSYNC_CLEAN_KEEP_IGNORED"
+	EnvCleanProtected           string = "GITSYNC_CLEAN_PROTECTED"
+	EnvPush                     string = "GITSYNC_PUSH"
+	EnvPushBranch               string = "GITSYNC_PUSH_BRANCH"
+	EnvPushStrategy             string = "GITSYNC_PUSH_STRATEGY"
+	EnvPushConflict             string = "GITSYNC_PUSH_CONFLICT"
+	EnvPushAuthorName           string = "GITSYNC_PUSH_AUTHOR_NAME"
+	EnvPushAuthorEmail          string = "GITSYNC_PUSH_AUTHOR_EMAIL"
+	EnvPushMessage              string = "GITSYNC_PUSH_MESSAGE"
+	EnvDryRun                   string = "GITSYNC_DRY_RUN"
+	EnvSparsePaths              string = "GITSYNC_SPARSE_PATHS"
+	EnvChangesInclude           string = "GITSYNC_CHANGES_INCLUDE"
+	EnvChangesExclude           string = "GITSYNC_CHANGES_EXCLUDE"
+	EnvCommitDirectives         string = "GITSYNC_COMMIT_DIRECTIVES"
+	EnvSubmodules               string = "GITSYNC_SUBMODULES"
+	EnvPublishLink              string = "GITSYNC_PUBLISH_LINK"
+	EnvPublishRoot              string = "GITSYNC_PUBLISH_ROOT"
+	EnvPublishKeep              string = "GITSYNC_PUBLISH_KEEP"
+	EnvSyncInterval             string = "GITSYNC_INTERVAL"
+	EnvOneTime                  string = "GITSYNC_ONE_TIME"
+	EnvHashFile                 string = "GITSYNC_HASH_FILE"
+	EnvSyncJitter               string = "GITSYNC_JITTER"
+	EnvRetryBackoff             string = "GITSYNC_RETRY_BACKOFF"
+	EnvRetryMaxBackoff          string = "GITSYNC_RETRY_MAX_BACKOFF"
+	EnvCloneTimeout             string = "GITSYNC_CLONE_TIMEOUT"
+	EnvFetchTimeout             string = "GITSYNC_FETCH_TIMEOUT"
+	EnvCheckoutTimeout          string = "GITSYNC_CHECKOUT_TIMEOUT"
+	EnvDataDir                  string = "GITSYNC_DATA_DIR"
+	EnvHistorySize              string = "GITSYNC_HISTORY_SIZE"
+	EnvHttpServerAddr           string = "GITSYNC_HTTP_SERVER_ADDR"
+	EnvHttpServerAuthUsername   string = "GITSYNC_HTTP_AUTH_USERNAME"
+	EnvHttpServerAuthPassword   string = "GITSYNC_HTTP_AUTH_PASSWORD"
+	EnvHttpServerAuthToken      string = "GITSYNC_HTTP_AUTH_TOKEN"
+)
+
+const (
+
+	// Способы аутентификации в удаленном репозитории
+	AuthModeAuto  string = ""      // определяется автоматически
+	AuthModeNone  string = "none"  // без аутентификации
+	AuthModeToken string = "token" // Bearer-токен
+	AuthModeBasic string = "basic" // имя пользователя и пароль (токен)
+	AuthModeSSH   string = "ssh"   // SSH-ключ
+
+	AuthModeGitHubApp string = "github-app" // токен установки GitHub App
+
+	// Адрес GitHub API по умолчанию
+	GitHubAPIURL string = "https://api.github.com"
+)
+
+const (
+
+	// Политики обработки локальных изменений
+	LocalChangesReset  string = "reset"  // отменить изменения
+	LocalChangesBackup string = "backup" // скопировать измененные файлы в каталог резервных копий и отменить изменения
+	LocalChangesStash  string = "stash"  // сохранить изменения в ссылку и отменить изменения
+	LocalChangesRefuse string = "refuse" // не выполнять синхронизацию и вернуть ошибку
+)
+
+const (
+
+	// Способы объединения локальных изменений с удаленной веткой при отправке
+	PushRebase string = "rebase" // коммит с локальными изменениями переносится на удаленную ветку
+	PushMerge  string = "merge"  // коммит с локальными изменениями объединяется с удаленной веткой
+
+	// Политики при конфликте локальных изменений с удаленной веткой
+	PushConflictFail     string = "fail"     // прервать синхронизацию, сохранив локальные изменения
+	PushConflictFallback string = "fallback" // обработать локальные изменения по политике local-changes и применить удаленную ветку
+
+	// Автор и шаблон сообщения коммита с локальными изменениями по умолчанию
+	PushAuthorName  string = "git-sync"
+	PushAuthorEmail string = "git-sync@localhost"
+	PushMessage     string = "git-sync: update {{.Count}} file(s) on {{.Hostname}}"
+)
+
+// PublishKeep - количество хранимых предыдущих опубликованных ревизий по умолчанию
+const PublishKeep int = 2
+
+const (
+
+	// Политики применения обновлений отслеживаемой ссылки
+	UpdateFollow string = "follow" // применить обновление
+	UpdateRefuse string = "refuse" // не применять обновление и вернуть ошибку
+	UpdateAlert  string = "alert"  // применить обновление с предупреждением
+)
+
+const (
+
+	// Коды завершения
+	ExitOK         int = 0 // синхронизация выполнена успешно
+	ExitSyncError  int = 1 // прочие ошибки синхронизации
+	ExitValidation int = 2 // некорректные параметры
+	ExitAuth       int = 3 // ошибка аутентификации или авторизации
+	ExitNetwork    int = 4 // сервер недоступен или превышено время ожидания
 )
@@ -22,11 +22,20 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 )
 
+// scpLikeURLRegExp соответствует адресам репозиториев вида [user@]host:path
+var scpLikeURLRegExp = regexp.MustCompile(`^(?:[^@/\s]+@)?[^:/\s]+:[^\\].*$`)
+
+// commitHashRegExp соответствует полному или сокращенному хешу коммита
+var commitHashRegExp = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
 // FlagSet представляет набор флагов командной строки.
 type ConsoleFlags struct {
 	Gitsync *flag.FlagSet
@@ -48,13 +57,79 @@ func ParseFlags() *flag.FlagSet {
 	fs := flag.NewFlagSet("git-sync", flag.ExitOnError)
 
 	fs.String(constants.FlagLocalPath, getEnv(constants.EnvLocalPath, ""), fmt.Sprintf("Путь к локальному репозиторию (%s)", constants.EnvLocalPath))
+	fs.String(constants.FlagLocalChanges, getEnv(constants.EnvLocalChanges, constants.LocalChangesReset), fmt.Sprintf("Обработка локальных изменений: reset, backup, stash, refuse (%s)", constants.EnvLocalChanges))
+	fs.String(constants.FlagLocalBackupDir, getEnv(constants.EnvLocalBackupDir, ""), fmt.Sprintf("Каталог резервных копий локальных изменений, по умолчанию <local-path>.backup (%s)", constants.EnvLocalBackupDir))
+	fs.Bool(constants.FlagClean, getEnvBool(constants.EnvClean, false), fmt.Sprintf("Удалять неотслеживаемые файлы и каталоги (%s)", constants.EnvClean))
+	fs.Bool(constants.FlagCleanKeepIgnored, getEnvBool(constants.EnvCleanKeepIgnored, true), fmt.Sprintf("Не удалять игнорируемые файлы (.gitignore) при очистке (%s)", constants.EnvCleanKeepIgnored))
+	fs.String(constants.FlagCleanProtected, getEnv(constants.EnvCleanProtected, ""), fmt.Sprintf("Шаблоны путей через запятую, которые не удаляются при очистке (%s)", constants.EnvCleanProtected))
+	fs.Bool(constants.FlagPush, getEnvBool(constants.EnvPush, false), fmt.Sprintf("Фиксировать локальные изменения и отправлять их в удаленный репозиторий вместо отмены (%s)", constants.EnvPush))
+	fs.String(constants.FlagPushBranch, getEnv(constants.EnvPushBranch, ""), fmt.Sprintf("Ветка для отправки локальных изменений, по умолчанию отслеживаемая ветка (%s)", constants.EnvPushBranch))
+	fs.String(constants.FlagPushStrategy, getEnv(constants.EnvPushStrategy, constants.PushRebase), fmt.Sprintf("Объединение с удаленной веткой: rebase, merge (%s)", constants.EnvPushStrategy))
+	fs.String(constants.FlagPushConflict, getEnv(constants.EnvPushConflict, constants.PushConflictFail), fmt.Sprintf("Действие при конфликте с удаленной веткой: fail, fallback (%s)", constants.EnvPushConflict))
+	fs.String(constants.FlagPushAuthorName, getEnv(constants.EnvPushAuthorName, constants.PushAuthorName), fmt.Sprintf("Имя автора коммита с локальными изменениями (%s)", constants.EnvPushAuthorName))
+	fs.String(constants.FlagPushAuthorEmail, getEnv(constants.EnvPushAuthorEmail, constants.PushAuthorEmail), fmt.Sprintf("Email автора коммита с локальными изменениями (%s)", constants.EnvPushAuthorEmail))
+	fs.String(constants.FlagPushMessage, getEnv(constants.EnvPushMessage, constants.PushMessage), fmt.Sprintf("Шаблон сообщения коммита с локальными изменениями (text/template: .Files, .Count, .Branch, .Hostname, .Time) (%s)", constants.EnvPushMessage))
+	fs.Bool(constants.FlagDryRun, getEnvBool(constants.EnvDryRun, false), fmt.Sprintf("Только сообщать о действиях синхронизации, не изменяя рабочий каталог (%s)", constants.EnvDryRun))
+	fs.String(constants.FlagSparsePaths, getEnv(constants.EnvSparsePaths, ""), fmt.Sprintf("Шаблоны путей частичного checkout через запятую (%s)", constants.EnvSparsePaths))
+	fs.String(constants.FlagChangesInclude, getEnv(constants.EnvChangesInclude, ""), fmt.Sprintf("Шаблоны путей через запятую, изменения которых считаются изменениями синхронизации, по умолчанию все пути (%s)", constants.EnvChangesInclude))
+	fs.String(constants.FlagChangesExclude, getEnv(constants.EnvChangesExclude, ""), fmt.Sprintf("Шаблоны путей через запятую, изменения которых не считаются изменениями синхронизации (%s)", constants.EnvChangesExclude))
+	fs.Bool(constants.FlagCommitDirectives, getEnvBool(constants.EnvCommitDirectives, true), fmt.Sprintf("Учитывать директивы [skip sync] и [hold] в сообщениях коммитов (%s)", constants.EnvCommitDirectives))
+	fs.Bool(constants.FlagSubmodules, getEnvBool(constants.EnvSubmodules, false), fmt.Sprintf("Рекурсивная синхронизация подмодулей (%s)", constants.EnvSubmodules))
+
+	fs.String(constants.FlagPublishLink, getEnv(constants.EnvPublishLink, ""), fmt.Sprintf("Символическая ссылка, которая атомарно переключается на каталог каждой новой ревизии (%s)", constants.EnvPublishLink))
+	fs.String(constants.FlagPublishRoot, getEnv(constants.EnvPublishRoot, ""), fmt.Sprintf("Каталог для ревизий, по умолчанию .revisions рядом со ссылкой (%s)", constants.EnvPublishRoot))
+	fs.Int(constants.FlagPublishKeep, getEnvInt(constants.EnvPublishKeep, constants.PublishKeep), fmt.Sprintf("Количество хранимых предыдущих ревизий (%s)", constants.EnvPublishKeep))
 
 	fs.String(constants.FlagRepoUrl, getEnv(constants.EnvRepoUrl, ""), fmt.Sprintf("URL удаленного репозитория (%s)", constants.EnvRepoUrl))
 	fs.String(constants.FlagRepoBranch, getEnv(constants.EnvRepoBranch, ""), fmt.Sprintf("Ветка удаленного репозитория (%s)", constants.EnvRepoBranch))
+	fs.String(constants.FlagRepoTag, getEnv(constants.EnvRepoTag, ""), fmt.Sprintf("Тег или шаблон тегов удаленного репозитория вместо ветки (%s)", constants.EnvRepoTag))
+	fs.String(constants.FlagRepoTagConstraint, getEnv(constants.EnvRepoTagConstraint, ""), fmt.Sprintf("Ограничение версий semver для выбора последнего тега, например \">=1.2.0, <2.0.0\" (%s)", constants.EnvRepoTagConstraint))
+	fs.String(constants.FlagRepoCommit, getEnv(constants.EnvRepoCommit, ""), fmt.Sprintf("Хеш коммита удаленного репозитория вместо ветки (%s)", constants.EnvRepoCommit))
+	fs.Bool(constants.FlagFFOnly, getEnvBool(constants.EnvFFOnly, false), fmt.Sprintf("Применять обновление, только если новый коммит - потомок текущего (%s)", constants.EnvFFOnly))
+	fs.String(constants.FlagUpdateFastForward, getEnv(constants.EnvUpdateFastForward, constants.UpdateFollow), fmt.Sprintf("Политика для обновлений fast-forward: follow, refuse, alert (%s)", constants.EnvUpdateFastForward))
+	fs.String(constants.FlagUpdateRewrite, getEnv(constants.EnvUpdateRewrite, constants.UpdateFollow), fmt.Sprintf("Политика для переписанной истории (force-push): follow, refuse, alert (%s)", constants.EnvUpdateRewrite))
+	fs.String(constants.FlagUpdateRollback, getEnv(constants.EnvUpdateRollback, constants.UpdateFollow), fmt.Sprintf("Политика для отката к предку текущего коммита: follow, refuse, alert (%s)", constants.EnvUpdateRollback))
 	fs.String(constants.FlagRepoAuthUser, getEnv(constants.EnvRepoAuthUser, ""), fmt.Sprintf("Учетная запись (%s)", constants.EnvRepoAuthUser))
 	fs.String(constants.FlagRepoAuthToken, getEnv(constants.EnvRepoAuthToken, ""), fmt.Sprintf("Токен авторизации (%s)", constants.EnvRepoAuthToken))
+	fs.String(constants.FlagRepoAuthUserFile, getEnv(constants.EnvRepoAuthUserFile, ""), fmt.Sprintf("Файл с учетной записью, читается перед каждой операцией (%s)", constants.EnvRepoAuthUserFile))
+	fs.String(constants.FlagRepoAuthTokenFile, getEnv(constants.EnvRepoAuthTokenFile, ""), fmt.Sprintf("Файл с токеном авторизации, читается перед каждой операцией (%s)", constants.EnvRepoAuthTokenFile))
+	fs.String(constants.FlagRepoNetrc, getEnv(constants.EnvRepoNetrc, ""), fmt.Sprintf("Файл .netrc с учетными данными (%s)", constants.EnvRepoNetrc))
+	fs.String(constants.FlagRepoCredentialHelper, getEnv(constants.EnvRepoCredentialHelper, ""), fmt.Sprintf("Программа git credential helper: имя, абсолютный путь или команда, начинающаяся с \"!\" (%s)", constants.EnvRepoCredentialHelper))
+	fs.String(constants.FlagRepoAuthMode, getEnv(constants.EnvRepoAuthMode, constants.AuthModeAuto), fmt.Sprintf("Способ аутентификации: none, token, basic, ssh, github-app (%s)", constants.EnvRepoAuthMode))
+	fs.String(constants.FlagRepoGitHubAppID, getEnv(constants.EnvRepoGitHubAppID, ""), fmt.Sprintf("Идентификатор (App ID или Client ID) приложения GitHub App (%s)", constants.EnvRepoGitHubAppID))
+	fs.String(constants.FlagRepoGitHubInstallationID, getEnv(constants.EnvRepoGitHubInstallationID, ""), fmt.Sprintf("Идентификатор установки приложения GitHub App (%s)", constants.EnvRepoGitHubInstallationID))
+	fs.String(constants.FlagRepoGitHubAppKeyFile, getEnv(constants.EnvRepoGitHubAppKeyFile, ""), fmt.Sprintf("Файл закрытого ключа приложения GitHub App в формате PEM (%s)", constants.EnvRepoGitHubAppKeyFile))
+	fs.String(constants.FlagRepoGitHubAPIURL, getEnv(constants.EnvRepoGitHubAPIURL, constants.GitHubAPIURL), fmt.Sprintf("Адрес GitHub API для получения токена установки (%s)", constants.EnvRepoGitHubAPIURL))
+
+	fs.Int(constants.FlagRepoDepth, getEnvInt(constants.EnvRepoDepth, 0), fmt.Sprintf("Глубина истории при клонировании и получении изменений, 0 - полная история (%s)", constants.EnvRepoDepth))
+	fs.Bool(constants.FlagRepoDeepen, getEnvBool(constants.EnvRepoDeepen, true), fmt.Sprintf("Автоматически углублять историю, если ее недостаточно для операции (%s)", constants.EnvRepoDeepen))
+
+	fs.String(constants.FlagRepoSSHKey, getEnv(constants.EnvRepoSSHKey, ""), fmt.Sprintf("Закрытый SSH-ключ в формате PEM (%s)", constants.EnvRepoSSHKey))
+	fs.String(constants.FlagRepoSSHKeyFile, getEnv(constants.EnvRepoSSHKeyFile, ""), fmt.Sprintf("Путь к файлу закрытого SSH-ключа (%s)", constants.EnvRepoSSHKeyFile))
+	fs.String(constants.FlagRepoSSHKeyPassphrase, getEnv(constants.EnvRepoSSHKeyPassphrase, ""), fmt.Sprintf("Пароль закрытого SSH-ключа (%s)", constants.EnvRepoSSHKeyPassphrase))
+	fs.String(constants.FlagRepoSSHKeyPassphraseFile, getEnv(constants.EnvRepoSSHKeyPassphraseFile, ""), fmt.Sprintf("Файл с паролем закрытого SSH-ключа (%s)", constants.EnvRepoSSHKeyPassphraseFile))
+	fs.String(constants.FlagRepoSSHKnownHosts, getEnv(constants.EnvRepoSSHKnownHosts, ""), fmt.Sprintf("Путь к файлу known_hosts (%s)", constants.EnvRepoSSHKnownHosts))
+	fs.Bool(constants.FlagRepoSSHStrictHostKey, getEnvBool(constants.EnvRepoSSHStrictHostKey, true), fmt.Sprintf("Строгая проверка ключа SSH-сервера (%s)", constants.EnvRepoSSHStrictHostKey))
+
+	fs.String(constants.FlagRepoCAFile, getEnv(constants.EnvRepoCAFile, ""), fmt.Sprintf("Файл с дополнительными корневыми сертификатами в формате PEM (%s)", constants.EnvRepoCAFile))
+	fs.String(constants.FlagRepoClientCert, getEnv(constants.EnvRepoClientCert, ""), fmt.Sprintf("Файл клиентского сертификата для mTLS в формате PEM (%s)", constants.EnvRepoClientCert))
+	fs.String(constants.FlagRepoClientKey, getEnv(constants.EnvRepoClientKey, ""), fmt.Sprintf("Файл закрытого ключа клиентского сертификата в формате PEM (%s)", constants.EnvRepoClientKey))
+	fs.Bool(constants.FlagRepoInsecureSkipVerify, getEnvBool(constants.EnvRepoInsecureSkipVerify, false), fmt.Sprintf("Не проверять сертификат сервера удаленного репозитория (%s)", constants.EnvRepoInsecureSkipVerify))
+	fs.Bool(constants.FlagAllowCLISecrets, getEnvBool(constants.EnvAllowCLISecrets, false), fmt.Sprintf("Разрешить передачу секретов в аргументах командной строки (%s)", constants.EnvAllowCLISecrets))
+	fs.String(constants.FlagRepoProxy, getEnv(constants.EnvRepoProxy, ""), fmt.Sprintf("Прокси-сервер, по умолчанию HTTPS_PROXY и HTTP_PROXY (%s)", constants.EnvRepoProxy))
+	fs.String(constants.FlagRepoNoProxy, getEnv(constants.EnvRepoNoProxy, ""), fmt.Sprintf("Адреса без прокси-сервера через запятую, по умолчанию NO_PROXY (%s)", constants.EnvRepoNoProxy))
 
 	fs.Duration(constants.FlagSyncInterval, getEnvDuration(constants.EnvSyncInterval, 30*time.Second), fmt.Sprintf("Интервал обновления репозитория (%s)", constants.EnvSyncInterval))
+	fs.Bool(constants.FlagOneTime, getEnvBool(constants.EnvOneTime, false), fmt.Sprintf("Однократная синхронизация с завершением работы (%s)", constants.EnvOneTime))
+	fs.String(constants.FlagHashFile, getEnv(constants.EnvHashFile, ""), fmt.Sprintf("Файл для записи хеша текущего коммита, \"-\" - стандартный вывод (%s)", constants.EnvHashFile))
+	fs.Float64(constants.FlagSyncJitter, getEnvFloat(constants.EnvSyncJitter, 0.1), fmt.Sprintf("Случайный разброс интервала и задержки повтора, доля от 0 до 1 (%s)", constants.EnvSyncJitter))
+	fs.Duration(constants.FlagRetryBackoff, getEnvDuration(constants.EnvRetryBackoff, 5*time.Second), fmt.Sprintf("Начальная задержка повтора после ошибки синхронизации, 0 - повтор через интервал обновления (%s)", constants.EnvRetryBackoff))
+	fs.Duration(constants.FlagRetryMaxBackoff, getEnvDuration(constants.EnvRetryMaxBackoff, 5*time.Minute), fmt.Sprintf("Максимальная задержка повтора после ошибок синхронизации, 0 - не больше интервала обновления (%s)", constants.EnvRetryMaxBackoff))
+	fs.Duration(constants.FlagCloneTimeout, getEnvDuration(constants.EnvCloneTimeout, 0), fmt.Sprintf("Ограничение времени клонирования, 0 - без ограничения (%s)", constants.EnvCloneTimeout))
+	fs.Duration(constants.FlagFetchTimeout, getEnvDuration(constants.EnvFetchTimeout, 0), fmt.Sprintf("Ограничение времени получения изменений, 0 - без ограничения (%s)", constants.EnvFetchTimeout))
+	fs.Duration(constants.FlagCheckoutTimeout, getEnvDuration(constants.EnvCheckoutTimeout, 0), fmt.Sprintf("Ограничение времени переключения рабочего каталога, 0 - без ограничения (%s)", constants.EnvCheckoutTimeout))
+	fs.String(constants.FlagDataDir, getEnv(constants.EnvDataDir, ""), fmt.Sprintf("Каталог данных сервиса (история синхронизаций), по умолчанию данные хранятся только в памяти (%s)", constants.EnvDataDir))
+	fs.Int(constants.FlagHistorySize, getEnvInt(constants.EnvHistorySize, 100), fmt.Sprintf("Количество хранимых записей истории синхронизаций, 0 - не вести историю (%s)", constants.EnvHistorySize))
 
 	fs.String(constants.FlagHttpServerAddr, getEnv(constants.EnvHttpServerAddr, ""), fmt.Sprintf("Адрес http-сервера (+порт) (%s)", constants.EnvHttpServerAddr))
 	fs.String(constants.FlagHttpServerAuthUsername, getEnv(constants.EnvHttpServerAuthUsername, ""), fmt.Sprintf("Имя пользователя http-сервера (%s)", constants.EnvHttpServerAuthUsername))
@@ -71,7 +146,6 @@ func (cf *ConsoleFlags) CheckRequiredFlags() error {
 
 	requiredFlags := []string{
 		constants.FlagRepoUrl,
-		constants.FlagRepoBranch,
 		constants.FlagLocalPath,
 	}
 
@@ -110,20 +184,83 @@ func validateFlags(fs *flag.FlagSet) error {
 		return err
 	}
 
-	// Repo Branch
-	validateFlagOptional(fs, constants.FlagRepoBranch, "Repository Branch")
+	// Update policies
+	for _, fn := range []string{constants.FlagUpdateFastForward, constants.FlagUpdateRewrite, constants.FlagUpdateRollback} {
+		if err := validateFlagUpdatePolicy(fs, fn, "Update Policy"); err != nil {
+			return err
+		}
+	}
 
-	// Repo user
-	validateFlagOptional(fs, constants.FlagRepoAuthUser, "Repository User")
+	// Local changes
+	if err := validateFlagLocalChanges(fs, constants.FlagLocalChanges, "Local Changes"); err != nil {
+		return err
+	}
 
-	// Repo token
-	validateFlagOptional(fs, constants.FlagRepoAuthToken, "Repository Token")
+	// Repo Branch / Tag / Commit
+	if err := validateFlagsRef(fs); err != nil {
+		return err
+	}
+
+	// Push
+	if err := validateFlagsPush(fs); err != nil {
+		return err
+	}
+
+	// Repo credentials
+	if err := validateFlagsCredentials(fs); err != nil {
+		return err
+	}
+
+	// Repo depth
+	if err := validateFlagNonNegativeInt(fs, constants.FlagRepoDepth, "Repository Depth"); err != nil {
+		return err
+	}
+
+	// Repo auth mode
+	if err := validateFlagAuthMode(fs, constants.FlagRepoAuthMode, "Repository Auth"); err != nil {
+		return err
+	}
+
+	// Repo SSH key
+	if err := validateFlagsSSH(fs); err != nil {
+		return err
+	}
+
+	// Repo TLS and proxy
+	if err := validateFlagsTLS(fs); err != nil {
+		return err
+	}
+
+	// Publish
+	if err := validateFlagNonNegativeInt(fs, constants.FlagPublishKeep, "Publish Keep"); err != nil {
+		return err
+	}
+	if err := validateFlagsPublish(fs); err != nil {
+		return err
+	}
+
+	// Sync history
+	if err := validateFlagNonNegativeInt(fs, constants.FlagHistorySize, "History Size"); err != nil {
+		return err
+	}
 
 	// Sync interval
 	if err := validateFlagSyncInterval(fs, constants.FlagSyncInterval, "Sync Interval"); err != nil {
 		return err
 	}
 
+	// Retry backoff
+	if err := validateFlagsRetry(fs); err != nil {
+		return err
+	}
+
+	// Timeouts
+	for _, fn := range []string{constants.FlagCloneTimeout, constants.FlagFetchTimeout, constants.FlagCheckoutTimeout} {
+		if err := validateFlagNonNegativeDuration(fs, fn, "Timeout"); err != nil {
+			return err
+		}
+	}
+
 	// HTTP Server Addr
 	if err := validateFlagsHttpServer(fs); err != nil {
 		return err
@@ -132,6 +269,34 @@ func validateFlags(fs *flag.FlagSet) error {
 	return nil
 }
 
+// LookupValue возвращает типизированное значение флага или значение по умолчанию, если флаг не определен.
+func LookupValue[T any](fs *flag.FlagSet, flagName string, defaultValue T) T {
+	f := fs.Lookup(flagName)
+	if f == nil {
+		return defaultValue
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return defaultValue
+	}
+	value, ok := getter.Get().(T)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// SplitList разбивает строку со списком значений через запятую, пропуская пустые элементы.
+func SplitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getFlagValue(fs *flag.FlagSet, flagName string) (string, bool) {
 	if f := fs.Lookup(flagName); f != nil {
 		value := f.Value.String()
@@ -163,6 +328,45 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return duration
 }
 
+// getEnvInt возвращает значение переменной окружения в формате int или значение по умолчанию, если переменная не установлена или имеет некорректный формат.
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// getEnvBool возвращает значение переменной окружения в формате bool или значение по умолчанию, если переменная не установлена или имеет некорректный формат.
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+// getEnvFloat возвращает значение переменной окружения в формате float64 или значение по умолчанию, если переменная не установлена или имеет некорректный формат.
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
 func validateFlagURL(fs *flag.FlagSet, fn string, desc string) error {
 
 	repoUrl, isExists := getFlagValue(fs, fn)
@@ -172,6 +376,23 @@ func validateFlagURL(fs *flag.FlagSet, fn string, desc string) error {
 		return fmt.Errorf("%s is not set", desc)
 	}
 
+	// Адреса вида ssh://[user@]host[:port]/path
+	if strings.HasPrefix(repoUrl, "ssh://") {
+		u, err := url.Parse(repoUrl)
+		if err != nil {
+			return err
+		}
+		if u.Host == "" {
+			return fmt.Errorf("%s: host is empty", desc)
+		}
+		return nil
+	}
+
+	// Адреса вида [user@]host:path (scp-like)
+	if !strings.Contains(repoUrl, "://") && scpLikeURLRegExp.MatchString(repoUrl) {
+		return nil
+	}
+
 	// Проверка корректности указанного URL
 	_, err := url.ParseRequestURI(repoUrl)
 	if err != nil {
@@ -259,3 +480,359 @@ func validateFlagsHttpServer(fs *flag.FlagSet) error {
 
 	return nil
 }
+
+func validateFlagNonNegativeInt(fs *flag.FlagSet, fn string, desc string) error {
+
+	fv, isExists := getFlagValue(fs, fn)
+	if !isExists {
+		return nil
+	}
+
+	value, err := strconv.Atoi(fv)
+	if err != nil {
+		return fmt.Errorf("%s must be an integer", desc)
+	}
+	if value < 0 {
+		return fmt.Errorf("%s must not be negative", desc)
+	}
+	return nil
+}
+
+func validateFlagsRetry(fs *flag.FlagSet) error {
+
+	if fv, isExists := getFlagValue(fs, constants.FlagSyncJitter); isExists {
+		jitter, err := strconv.ParseFloat(fv, 64)
+		if err != nil {
+			return fmt.Errorf("sync jitter must be a number")
+		}
+		if jitter < 0 || jitter > 1 {
+			return fmt.Errorf("sync jitter must be between 0 and 1")
+		}
+	}
+
+	for _, fn := range []string{constants.FlagRetryBackoff, constants.FlagRetryMaxBackoff} {
+		if err := validateFlagNonNegativeDuration(fs, fn, "Retry Backoff"); err != nil {
+			return err
+		}
+	}
+
+	backoff := LookupValue(fs, constants.FlagRetryBackoff, time.Duration(0))
+	maxBackoff := LookupValue(fs, constants.FlagRetryMaxBackoff, time.Duration(0))
+	if backoff > 0 && maxBackoff > 0 && maxBackoff < backoff {
+		return fmt.Errorf("%s must not be less than %s", constants.FlagRetryMaxBackoff, constants.FlagRetryBackoff)
+	}
+
+	return nil
+}
+
+func validateFlagNonNegativeDuration(fs *flag.FlagSet, fn string, desc string) error {
+
+	fv, isExists := getFlagValue(fs, fn)
+	if !isExists {
+		return nil
+	}
+
+	value, err := time.ParseDuration(fv)
+	if err != nil {
+		return fmt.Errorf("%s %s must be a duration", desc, fn)
+	}
+	if value < 0 {
+		return fmt.Errorf("%s %s must not be negative", desc, fn)
+	}
+	return nil
+}
+
+func validateFlagAuthMode(fs *flag.FlagSet, fn string, desc string) error {
+
+	mode, _ := getFlagValue(fs, fn)
+
+	switch mode {
+	case constants.AuthModeAuto, constants.AuthModeNone, constants.AuthModeToken, constants.AuthModeSSH:
+		return nil
+	case constants.AuthModeBasic:
+		user, _ := getFlagValue(fs, constants.FlagRepoAuthUser)
+		userFile, _ := getFlagValue(fs, constants.FlagRepoAuthUserFile)
+		if user == "" && userFile == "" {
+			return fmt.Errorf("%s: basic authentication requires repository user", desc)
+		}
+		return nil
+	case constants.AuthModeGitHubApp:
+		for _, fn := range []string{constants.FlagRepoGitHubAppID, constants.FlagRepoGitHubInstallationID, constants.FlagRepoGitHubAppKeyFile} {
+			if value, _ := getFlagValue(fs, fn); value == "" {
+				return fmt.Errorf("%s: GitHub App authentication requires %s", desc, fn)
+			}
+		}
+		keyFile, _ := getFlagValue(fs, constants.FlagRepoGitHubAppKeyFile)
+		if _, err := os.Stat(keyFile); err != nil {
+			return fmt.Errorf("GitHub App private key file is not accessible: %s", keyFile)
+		}
+		apiURL, _ := getFlagValue(fs, constants.FlagRepoGitHubAPIURL)
+		if u, err := url.Parse(apiURL); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid GitHub API URL: %s", apiURL)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown authentication mode %q", desc, mode)
+	}
+}
+
+func validateFlagUpdatePolicy(fs *flag.FlagSet, fn string, desc string) error {
+
+	policy, _ := getFlagValue(fs, fn)
+
+	switch policy {
+	case constants.UpdateFollow, constants.UpdateRefuse, constants.UpdateAlert:
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown policy %q for %s", desc, policy, fn)
+	}
+}
+
+func validateFlagLocalChanges(fs *flag.FlagSet, fn string, desc string) error {
+
+	policy, _ := getFlagValue(fs, fn)
+
+	switch policy {
+	case constants.LocalChangesReset, constants.LocalChangesStash, constants.LocalChangesRefuse:
+		return nil
+	case constants.LocalChangesBackup:
+		// Резервные копии не должны попадать в рабочий каталог локального репозитория
+		backupDir, _ := getFlagValue(fs, constants.FlagLocalBackupDir)
+		localPath, _ := getFlagValue(fs, constants.FlagLocalPath)
+		if len(backupDir) > 0 && isSubPath(localPath, backupDir) {
+			return fmt.Errorf("%s: %s must be outside of %s", desc, backupDir, localPath)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown policy %q", desc, policy)
+	}
+}
+
+func validateFlagsRef(fs *flag.FlagSet) error {
+
+	branch, _ := getFlagValue(fs, constants.FlagRepoBranch)
+	tag, _ := getFlagValue(fs, constants.FlagRepoTag)
+	tagConstraint, _ := getFlagValue(fs, constants.FlagRepoTagConstraint)
+	commit, _ := getFlagValue(fs, constants.FlagRepoCommit)
+
+	refs := 0
+	for _, value := range []string{branch, tag + tagConstraint, commit} {
+		if len(value) > 0 {
+			refs++
+		}
+	}
+
+	switch {
+	case refs == 0:
+		return fmt.Errorf("one of %s, %s, %s must be set",
+			constants.FlagRepoBranch, constants.FlagRepoTag+"/"+constants.FlagRepoTagConstraint, constants.FlagRepoCommit)
+	case refs > 1:
+		return fmt.Errorf("only one of %s, %s, %s can be set",
+			constants.FlagRepoBranch, constants.FlagRepoTag+"/"+constants.FlagRepoTagConstraint, constants.FlagRepoCommit)
+	}
+
+	if len(commit) > 0 && !commitHashRegExp.MatchString(commit) {
+		return fmt.Errorf("Repository Commit: invalid commit hash %q", commit)
+	}
+
+	return nil
+}
+
+func validateFlagsPush(fs *flag.FlagSet) error {
+
+	if !LookupValue(fs, constants.FlagPush, false) {
+		return nil
+	}
+
+	// Изменения фиксируются поверх отслеживаемой ветки
+	if branch, _ := getFlagValue(fs, constants.FlagRepoBranch); branch == "" {
+		return fmt.Errorf("%s requires %s", constants.FlagPush, constants.FlagRepoBranch)
+	}
+	if paths, _ := getFlagValue(fs, constants.FlagSparsePaths); paths != "" {
+		return fmt.Errorf("%s cannot be used with %s", constants.FlagPush, constants.FlagSparsePaths)
+	}
+
+	switch strategy, _ := getFlagValue(fs, constants.FlagPushStrategy); strategy {
+	case constants.PushRebase, constants.PushMerge:
+	default:
+		return fmt.Errorf("Push Strategy: unknown strategy %q", strategy)
+	}
+
+	switch policy, _ := getFlagValue(fs, constants.FlagPushConflict); policy {
+	case constants.PushConflictFail, constants.PushConflictFallback:
+	default:
+		return fmt.Errorf("Push Conflict: unknown policy %q", policy)
+	}
+
+	if message, _ := getFlagValue(fs, constants.FlagPushMessage); message != "" {
+		if _, err := template.New(constants.FlagPushMessage).Parse(message); err != nil {
+			return fmt.Errorf("invalid push message template: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func validateFlagsPublish(fs *flag.FlagSet) error {
+
+	link, _ := getFlagValue(fs, constants.FlagPublishLink)
+	if len(link) == 0 {
+		return nil
+	}
+
+	root, _ := getFlagValue(fs, constants.FlagPublishRoot)
+	if len(root) == 0 {
+		root = filepath.Join(filepath.Dir(link), ".revisions")
+	}
+
+	// Ссылка и ревизии не должны попадать в рабочий каталог локального репозитория
+	localPath, _ := getFlagValue(fs, constants.FlagLocalPath)
+	for _, p := range []string{link, root} {
+		if isSubPath(localPath, p) {
+			return fmt.Errorf("Publish: %s must be outside of %s", p, localPath)
+		}
+	}
+
+	return nil
+}
+
+// isSubPath проверяет, находится ли путь p внутри каталога dir (или совпадает с ним)
+func isSubPath(dir, p string) bool {
+
+	dir, errDir := filepath.Abs(dir)
+	p, errPath := filepath.Abs(p)
+	if errDir != nil || errPath != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func validateFlagsSSH(fs *flag.FlagSet) error {
+
+	keyFile, _ := getFlagValue(fs, constants.FlagRepoSSHKeyFile)
+	if len(keyFile) > 0 {
+		if _, err := os.Stat(keyFile); err != nil {
+			return fmt.Errorf("SSH key file is not accessible: %s", keyFile)
+		}
+	}
+
+	knownHosts, _ := getFlagValue(fs, constants.FlagRepoSSHKnownHosts)
+	if len(knownHosts) > 0 {
+		if _, err := os.Stat(knownHosts); err != nil {
+			return fmt.Errorf("SSH known_hosts file is not accessible: %s", knownHosts)
+		}
+	}
+
+	if strict, _ := getFlagValue(fs, constants.FlagRepoSSHStrictHostKey); strict == "false" {
+		logger.GetLogger().Warning("SSH: strict host key checking is disabled\n")
+	}
+
+	return nil
+}
+
+func validateFlagsTLS(fs *flag.FlagSet) error {
+
+	caFile, _ := getFlagValue(fs, constants.FlagRepoCAFile)
+	if len(caFile) > 0 {
+		if _, err := os.Stat(caFile); err != nil {
+			return fmt.Errorf("CA bundle file is not accessible: %s", caFile)
+		}
+	}
+
+	clientCert, _ := getFlagValue(fs, constants.FlagRepoClientCert)
+	clientKey, _ := getFlagValue(fs, constants.FlagRepoClientKey)
+	if (len(clientCert) > 0) != (len(clientKey) > 0) {
+		return fmt.Errorf("flags %s and %s must be set together", constants.FlagRepoClientCert, constants.FlagRepoClientKey)
+	}
+	for _, file := range []string{clientCert, clientKey} {
+		if len(file) == 0 {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("client certificate file is not accessible: %s", file)
+		}
+	}
+
+	if insecure, _ := getFlagValue(fs, constants.FlagRepoInsecureSkipVerify); insecure == "true" {
+		logger.GetLogger().Warning("TLS: certificate verification is disabled\n")
+	}
+
+	proxy, _ := getFlagValue(fs, constants.FlagRepoProxy)
+	if len(proxy) > 0 {
+		if u, err := url.Parse(proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL: %s", proxy)
+		}
+	}
+
+	return nil
+}
+
+func validateFlagsCredentials(fs *flag.FlagSet) error {
+
+	// Значение секрета и файл с ним не задаются одновременно
+	pairs := [][2]string{
+		{constants.FlagRepoAuthUser, constants.FlagRepoAuthUserFile},
+		{constants.FlagRepoAuthToken, constants.FlagRepoAuthTokenFile},
+		{constants.FlagRepoSSHKeyPassphrase, constants.FlagRepoSSHKeyPassphraseFile},
+	}
+	for _, pair := range pairs {
+		value, _ := getFlagValue(fs, pair[0])
+		file, _ := getFlagValue(fs, pair[1])
+		if len(value) > 0 && len(file) > 0 {
+			return fmt.Errorf("flags %s and %s are mutually exclusive", pair[0], pair[1])
+		}
+	}
+
+	files := []string{
+		constants.FlagRepoAuthUserFile,
+		constants.FlagRepoAuthTokenFile,
+		constants.FlagRepoSSHKeyPassphraseFile,
+		constants.FlagRepoNetrc,
+	}
+	for _, fn := range files {
+		file, _ := getFlagValue(fs, fn)
+		if len(file) == 0 {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("credentials file is not accessible: %s", file)
+		}
+	}
+
+	// Аргументы командной строки видны в списке процессов (ps),
+	// поэтому секреты в них принимаются только с явного разрешения
+	allowed, _ := getFlagValue(fs, constants.FlagAllowCLISecrets)
+	var exposed []string
+	secrets := map[string]bool{
+		constants.FlagRepoAuthToken:          true,
+		constants.FlagRepoSSHKey:             true,
+		constants.FlagRepoSSHKeyPassphrase:   true,
+		constants.FlagHttpServerAuthPassword: true,
+		constants.FlagHttpServerAuthToken:    true,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if secrets[f.Name] && f.Value.String() != "" {
+			exposed = append(exposed, f.Name)
+		}
+	})
+	for _, name := range exposed {
+		if allowed != "true" {
+			return fmt.Errorf("%s is passed on the command line and is visible in the process list, use an environment variable or a file instead (or set %s)", name, constants.FlagAllowCLISecrets)
+		}
+		logger.GetLogger().Warning("%s is passed on the command line and is visible in the process list, use an environment variable or a file instead\n", name)
+	}
+
+	// Учетные данные могут быть получены из файлов, .netrc или credential helper
+	for _, fn := range []string{constants.FlagRepoAuthUserFile, constants.FlagRepoAuthTokenFile, constants.FlagRepoNetrc, constants.FlagRepoCredentialHelper} {
+		if value, _ := getFlagValue(fs, fn); len(value) > 0 {
+			return nil
+		}
+	}
+
+	validateFlagOptional(fs, constants.FlagRepoAuthUser, "Repository User")
+	validateFlagOptional(fs, constants.FlagRepoAuthToken, "Repository Token")
+
+	return nil
+}
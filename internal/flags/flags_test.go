@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"git-sync/internal/constants"
 	"git-sync/logger"
 	"log"
 	"os"
@@ -94,6 +95,100 @@ func TestGetEnvDuration(t *testing.T) {
 	}
 }
 
+func TestGetEnvInt(t *testing.T) {
+
+	// Подготовим структуру для целочисленных переменных окружения
+	type getEnvTestCase struct {
+		envKey   string
+		envValue string
+		expected int
+	}
+
+	testCases := []getEnvTestCase{
+		{"TEST_ENV_INT_VALUE", "10", 10},
+		{"TEST_ENV_INT_EMPTY_VALUE", "", 0},
+		{"TEST_ENV_INT_DEFAULT_VALUE", "invalid", 5},
+	}
+
+	// Выполнение тестов
+	for _, tc := range testCases {
+		t.Run(tc.envKey, func(t *testing.T) {
+			os.Setenv(tc.envKey, tc.envValue)
+			defer os.Unsetenv(tc.envKey)
+			value := getEnvInt(tc.envKey, tc.expected)
+			if value != tc.expected {
+				t.Errorf("Expected '%d', got '%d'", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestGetEnvBool(t *testing.T) {
+
+	// Подготовим структуру для логических переменных окружения
+	type getEnvTestCase struct {
+		envKey       string
+		envValue     string
+		defaultValue bool
+		expected     bool
+	}
+
+	testCases := []getEnvTestCase{
+		{"TEST_ENV_BOOL_TRUE_VALUE", "true", false, true},
+		{"TEST_ENV_BOOL_FALSE_VALUE", "0", true, false},
+		{"TEST_ENV_BOOL_EMPTY_VALUE", "", true, true},
+		{"TEST_ENV_BOOL_INVALID_VALUE", "invalid", true, true},
+	}
+
+	// Выполнение тестов
+	for _, tc := range testCases {
+		t.Run(tc.envKey, func(t *testing.T) {
+			os.Setenv(tc.envKey, tc.envValue)
+			defer os.Unsetenv(tc.envKey)
+			value := getEnvBool(tc.envKey, tc.defaultValue)
+			if value != tc.expected {
+				t.Errorf("Expected '%t', got '%t'", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestLookupValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("stringFlag", "testValue", "")
+	fs.Bool("boolFlag", true, "")
+
+	if value := LookupValue(fs, "stringFlag", ""); value != "testValue" {
+		t.Errorf("Expected 'testValue', got '%s'", value)
+	}
+
+	if value := LookupValue(fs, "boolFlag", false); !value {
+		t.Errorf("Expected 'true', got '%t'", value)
+	}
+
+	// Флаг не определен
+	if value := LookupValue(fs, "nonExistentFlag", "defaultValue"); value != "defaultValue" {
+		t.Errorf("Expected 'defaultValue', got '%s'", value)
+	}
+
+	// Тип флага не совпадает с запрошенным
+	if value := LookupValue(fs, "stringFlag", 10); value != 10 {
+		t.Errorf("Expected '10', got '%d'", value)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+
+	list := SplitList(" deploy/prod, ,docs/** ,")
+	if len(list) != 2 || list[0] != "deploy/prod" || list[1] != "docs/**" {
+		t.Errorf("Unexpected list: %q", list)
+	}
+
+	if list := SplitList(""); len(list) != 0 {
+		t.Errorf("Expected empty list, got %q", list)
+	}
+}
+
 func TestValidateFlagURL(t *testing.T) {
 
 	tests := []struct {
@@ -110,6 +205,27 @@ func TestValidateFlagURL(t *testing.T) {
 			desc:      "URL Flag",
 			expected:  nil,
 		},
+		{
+			name:      "Flag exists and is set with valid SSH URL",
+			flagName:  "sshURLFlag",
+			flagValue: "ssh://git@example.com:2222/org/repo.git",
+			desc:      "SSH URL Flag",
+			expected:  nil,
+		},
+		{
+			name:      "Flag exists and is set with valid scp-like URL",
+			flagName:  "scpURLFlag",
+			flagValue: "git@example.com:org/repo.git",
+			desc:      "SCP URL Flag",
+			expected:  nil,
+		},
+		{
+			name:      "Flag exists but has SSH URL without host",
+			flagName:  "invalidSSHURLFlag",
+			flagValue: "ssh:///org/repo.git",
+			desc:      "Invalid SSH URL Flag",
+			expected:  fmt.Errorf("Invalid SSH URL Flag: host is empty"),
+		},
 		{
 			name:      "Flag does not exist",
 			flagName:  "nonExistentFlag",
@@ -244,3 +360,50 @@ func TestValidateFlagsHttpServer(t *testing.T) {
 		t.Errorf("Expected no error, got '%s'", err)
 	}
 }
+
+func TestIsSubPath(t *testing.T) {
+	tests := []struct {
+		dir, path string
+		expected  bool
+	}{
+		{"/data/repo", "/data/repo", true},
+		{"/data/repo", "/data/repo/current", true},
+		{"/data/repo", "/data/current", false},
+		{"/data/repo", "/data/repo-revisions", false},
+		{"/data/repo", "/data/repo/../current", false},
+	}
+
+	for _, tt := range tests {
+		if result := isSubPath(tt.dir, tt.path); result != tt.expected {
+			t.Errorf("isSubPath(%q, %q): expected %v, got %v", tt.dir, tt.path, tt.expected, result)
+		}
+	}
+}
+
+func TestValidateFlagsCredentialsCommandLine(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, name := range []string{
+		constants.FlagRepoAuthUser, constants.FlagRepoAuthUserFile,
+		constants.FlagRepoAuthToken, constants.FlagRepoAuthTokenFile,
+		constants.FlagRepoSSHKeyPassphrase, constants.FlagRepoSSHKeyPassphraseFile,
+		constants.FlagRepoNetrc, constants.FlagRepoCredentialHelper,
+	} {
+		fs.String(name, "", "")
+	}
+	fs.Bool(constants.FlagAllowCLISecrets, false, "")
+
+	// Токен в аргументах командной строки без явного разрешения отклоняется
+	if err := fs.Parse([]string{"--" + constants.FlagRepoAuthToken, "secret"}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	if err := validateFlagsCredentials(fs); err == nil {
+		t.Error("Expected error for token passed on the command line")
+	}
+
+	if err := fs.Parse([]string{"--" + constants.FlagAllowCLISecrets}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	if err := validateFlagsCredentials(fs); err != nil {
+		t.Errorf("Expected no error with %s, got '%v'", constants.FlagAllowCLISecrets, err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDelay(t *testing.T) {
+
+	gitSync := &GitSync{
+		interval:   30 * time.Second,
+		backoff:    time.Second,
+		maxBackoff: 10 * time.Second,
+	}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		if delay := gitSync.nextDelay(test.failures); delay != test.expected {
+			t.Errorf("nextDelay(%d) = %s, expected %s", test.failures, delay, test.expected)
+		}
+	}
+
+	// Без максимальной задержки повтор выполняется не реже интервала обновления
+	gitSync.maxBackoff = 0
+	if delay := gitSync.nextDelay(100); delay != gitSync.interval {
+		t.Errorf("nextDelay(100) = %s, expected %s", delay, gitSync.interval)
+	}
+
+	// Без начальной задержки повтор выполняется через интервал обновления
+	gitSync.backoff = 0
+	if delay := gitSync.nextDelay(3); delay != gitSync.interval {
+		t.Errorf("nextDelay(3) = %s, expected %s", delay, gitSync.interval)
+	}
+}
+
+func TestApplyJitter(t *testing.T) {
+
+	delay := 10 * time.Second
+
+	if applyJitter(delay, 0) != delay {
+		t.Errorf("Expected no jitter")
+	}
+
+	for i := 0; i < 100; i++ {
+		jittered := applyJitter(delay, 0.2)
+		if jittered < 8*time.Second || jittered > 12*time.Second {
+			t.Fatalf("Jittered delay %s is out of range", jittered)
+		}
+	}
+}
@@ -16,28 +16,64 @@ package gitsync
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"git-sync/git"
 	"git-sync/internal/constants"
+	"git-sync/internal/flags"
 	"git-sync/internal/handlers"
+	"git-sync/internal/history"
 	"git-sync/internal/interfaces"
 	"git-sync/internal/metrics"
+	"git-sync/internal/models"
 	"git-sync/logger"
+	"math/rand"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
 type GitSync struct {
-	ctx      context.Context
-	interval time.Duration // Интервал обновления репозитория
+	ctx        context.Context
+	interval   time.Duration // Интервал обновления репозитория
+	jitter     float64       // Случайный разброс интервала и задержки повтора (доля от 0 до 1)
+	backoff    time.Duration // Начальная задержка повтора после ошибки (0 - повтор через интервал)
+	maxBackoff time.Duration // Максимальная задержка повтора (0 - не больше интервала обновления)
+
+	mutex    sync.Mutex
+	status   models.SyncStatus // Состояние последней синхронизации
+	failures int               // Количество ошибок синхронизации подряд
+
+	resumeTimer *time.Timer // Таймер автоматического возобновления приостановленной синхронизации
+
+	history *history.History // История синхронизаций
+
+	commands chan func(gitRepo interfaces.Gitter) // Операции управления, выполняемые в цикле синхронизации
 }
 
 // NewGitSync создает экземпляр SyncOptions с значениями по умолчанию.
 func NewGitSync(f *flag.FlagSet, ctx context.Context) (*GitSync, error) {
 
 	gitSync := &GitSync{
-		ctx:      ctx,
-		interval: f.Lookup(constants.FlagSyncInterval).Value.(flag.Getter).Get().(time.Duration),
+		ctx:        ctx,
+		interval:   f.Lookup(constants.FlagSyncInterval).Value.(flag.Getter).Get().(time.Duration),
+		jitter:     flags.LookupValue(f, constants.FlagSyncJitter, 0.1),
+		backoff:    flags.LookupValue(f, constants.FlagRetryBackoff, 5*time.Second),
+		maxBackoff: flags.LookupValue(f, constants.FlagRetryMaxBackoff, 5*time.Minute),
+		commands:   make(chan func(gitRepo interfaces.Gitter)),
 	}
 
+	// История синхронизаций сохраняется в каталоге данных, если он задан
+	historyFile := ""
+	if dataDir := flags.LookupValue(f, constants.FlagDataDir, ""); dataDir != "" {
+		historyFile = filepath.Join(dataDir, history.FileName)
+	}
+	syncHistory, err := history.New(historyFile, flags.LookupValue(f, constants.FlagHistorySize, 100))
+	if err != nil {
+		return nil, err
+	}
+	gitSync.history = syncHistory
+
 	return gitSync, nil
 }
 
@@ -45,9 +81,13 @@ func (gitsync *GitSync) Start(gitRepo interfaces.Gitter) {
 
 	logger.GetLogger().Info("Sync: start synchronization\n")
 
-	// Создаем тикер для периодической синхронизации
-	ticker := time.NewTicker(gitsync.interval)
-	defer ticker.Stop()
+	// Начальное состояние - репозиторий после клонирования
+	gitsync.updateStatus(gitRepo, nil)
+
+	// Создаем таймер для периодической синхронизации.
+	// После каждой синхронизации таймер перезапускается с новой задержкой.
+	timer := time.NewTimer(gitsync.schedule())
+	defer timer.Stop()
 
 	for {
 		select {
@@ -58,32 +98,98 @@ func (gitsync *GitSync) Start(gitRepo interfaces.Gitter) {
 			return
 
 		case ip := <-handlers.WebhookCh:
-			// Синхронизация по вебхуку
-			_ = gitsync.Sync(gitRepo)
-			logger.GetLogger().Info("Sync: webhook synchronization (client IP: %s)\n", ip)
+			// Синхронизация по вебхуку, если синхронизация не приостановлена
+			if gitsync.Paused() != nil {
+				logger.GetLogger().Info("Sync: webhook ignored while paused (client IP: %s)\n", ip)
+			} else {
+				_ = gitsync.sync(gitRepo, models.TriggerWebhook)
+				logger.GetLogger().Info("Sync: webhook synchronization (client IP: %s)\n", ip)
+			}
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(gitsync.schedule())
 
-		case <-ticker.C:
-			// Синхронизация
-			_ = gitsync.Sync(gitRepo)
+		case <-timer.C:
+			// Синхронизация. При закрепленном коммите отслеживаемая ссылка не применяется,
+			// приостановленная синхронизация пропускается.
+			if gitsync.Paused() == nil {
+				_ = gitsync.sync(gitRepo, models.TriggerTick)
+			}
+			timer.Reset(gitsync.schedule())
+
+		case command := <-gitsync.commands:
+			// Операция управления (закрепление коммита) не выполняется одновременно с синхронизацией
+			command(gitRepo)
 		}
 	}
 }
 
+// Sync выполняет синхронизацию по запросу (источник manual в истории синхронизаций)
 func (gitsync *GitSync) Sync(gitRepo interfaces.Gitter) error {
+	return gitsync.sync(gitRepo, models.TriggerManual)
+}
+
+// sync выполняет синхронизацию, запущенную из источника trigger, обновляет метрики,
+// состояние и историю синхронизаций
+func (gitsync *GitSync) sync(gitRepo interfaces.Gitter, trigger string) error {
+
+	entry := models.HistoryEntry{
+		Start:   time.Now(),
+		Trigger: trigger,
+		OldHash: gitRepo.CommitHash(),
+	}
 
 	// Синхронизация локального репозитория
-	err := gitRepo.Sync()
-	if err != nil {
-		logger.GetLogger().Error("Sync error: %v", err)
+	syncErr := gitRepo.Sync(gitsync.ctx)
+	if syncErr != nil {
+		logger.GetLogger().Error("Sync error: %v", syncErr)
 		metrics.SyncTotalErrorCount.Inc()
 	}
 
+	// Считаем ошибки синхронизации подряд
+	gitsync.mutex.Lock()
+	if syncErr != nil {
+		gitsync.failures++
+	} else {
+		gitsync.failures = 0
+	}
+	metrics.SyncConsecutiveFailures.Set(float64(gitsync.failures))
+	gitsync.mutex.Unlock()
+
+	// Сохраняем состояние синхронизации
+	gitsync.updateStatus(gitRepo, syncErr)
+
+	// Увеличиваем счетчик обновлений отслеживаемой ссылки
+	if update := gitRepo.LastUpdate(); update != nil {
+		metrics.AddUpdate(update)
+	}
+
+	// Увеличиваем счетчик отправок локальных изменений
+	if push := gitRepo.LastPush(); push != nil {
+		metrics.AddPush(push)
+	}
+
+	// Обновляем метрику ревизии, задержанной директивой в сообщении коммита
+	metrics.UpdateHeldRevision(gitRepo.Held())
+
+	// Обновляем метрики действий, которые выполнила бы синхронизация в режиме dry-run
+	if plan := gitRepo.DryRun(); plan != nil {
+		metrics.UpdateDryRun(plan)
+	}
+
+	// Увеличиваем счетчик отклоненных обновлений в режиме fast-forward-only
+	if errors.Is(syncErr, git.ErrDiverged) {
+		metrics.SyncDivergedCount.Inc()
+	}
+
 	// Получаем текущий коммит
 	commit, err := gitRepo.Commit()
 	if err != nil {
 		logger.GetLogger().Error("%v\n", err)
 	} else {
-		metrics.UpdateCommitInfo(commit)
+		metrics.UpdateCommitInfo(commit, gitRepo.Pinned())
 	}
 
 	// Увеличиваем счетчик с общим количеством синхронизаций
@@ -95,11 +201,230 @@ func (gitsync *GitSync) Sync(gitRepo interfaces.Gitter) error {
 	if gitRepo.HasChanges() {
 		// Увеличиваем счетчик синхронизаций с изменениями
 		metrics.SyncCount.Inc()
+
+		// Увеличиваем счетчики изменений файлов
+		if commit != nil {
+			metrics.AddChanges(commit)
+		}
+	}
+
+	// Сохраняем запись в истории синхронизаций
+	entry.End = time.Now()
+	entry.Outcome = syncState(syncErr)
+	entry.NewHash = gitRepo.CommitHash()
+	if syncErr != nil {
+		entry.Error = syncErr.Error()
+	}
+	if commit != nil && gitRepo.HasChanges() {
+		entry.Changes = len(commit.RelevantChanges())
+	}
+	if _, err := gitsync.history.Add(entry); err != nil {
+		logger.GetLogger().Error("Sync: %v\n", err)
 	}
 
 	return nil
 }
 
+// History возвращает страницу истории синхронизаций
+func (gitsync *GitSync) History(query models.HistoryQuery) models.HistoryPage {
+	return gitsync.history.Query(query)
+}
+
+// syncState возвращает состояние синхронизации, завершившейся с ошибкой syncErr
+func syncState(syncErr error) string {
+	switch {
+	case syncErr == nil:
+		return models.StateOK
+	case errors.Is(syncErr, git.ErrDiverged):
+		return models.StateDiverged
+	case errors.Is(syncErr, git.ErrPushConflict):
+		return models.StateConflict
+	default:
+		return models.StateError
+	}
+}
+
+// updateStatus сохраняет состояние синхронизации репозитория
+func (gitsync *GitSync) updateStatus(gitRepo interfaces.Gitter, syncErr error) {
+
+	status := models.SyncStatus{
+		State:      syncState(syncErr),
+		Repository: gitRepo.Options().Url(),
+		Ref:        gitRepo.Options().Ref(),
+		HasChanges: gitRepo.HasChanges(),
+		LastSync:   time.Now(),
+		Pin:        gitRepo.Pinned(),
+		DryRun:     gitRepo.DryRun(),
+		Held:       gitRepo.Held(),
+	}
+
+	if commit, err := gitRepo.Commit(); err == nil {
+		status.Commit = commit
+	}
+
+	// Последнее обновление сохраняется, пока не будет найдено следующее
+	gitsync.mutex.Lock()
+	status.LastUpdate = gitsync.status.LastUpdate
+	status.LastPush = gitsync.status.LastPush
+	gitsync.mutex.Unlock()
+	if update := gitRepo.LastUpdate(); update != nil {
+		status.LastUpdate = update
+	}
+	if push := gitRepo.LastPush(); push != nil {
+		status.LastPush = push
+	}
+
+	if syncErr != nil {
+		status.LastError = syncErr.Error()
+	}
+
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+	status.ConsecutiveFailures = gitsync.failures
+	status.NextSync = gitsync.status.NextSync
+	status.Paused = gitsync.status.Paused
+	gitsync.status = status
+}
+
+// schedule вычисляет задержку до следующей синхронизации и сохраняет время следующей попытки
+func (gitsync *GitSync) schedule() time.Duration {
+
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+
+	delay := gitsync.nextDelay(gitsync.failures)
+	gitsync.status.NextSync = time.Now().Add(delay)
+	metrics.SyncNextTimestamp.Set(float64(gitsync.status.NextSync.Unix()))
+
+	return delay
+}
+
+// nextDelay возвращает задержку до следующей синхронизации: интервал обновления после успешной
+// синхронизации или экспоненциально растущую задержку после failures ошибок подряд.
+// К задержке добавляется случайный разброс, чтобы реплики не обращались к серверу одновременно.
+func (gitsync *GitSync) nextDelay(failures int) time.Duration {
+
+	delay := gitsync.interval
+
+	if failures > 0 && gitsync.backoff > 0 {
+		limit := gitsync.maxBackoff
+		if limit <= 0 {
+			limit = gitsync.interval
+		}
+		delay = gitsync.backoff
+		for i := 1; i < failures && delay < limit; i++ {
+			delay *= 2
+		}
+		if delay > limit {
+			delay = limit
+		}
+	}
+
+	return applyJitter(delay, gitsync.jitter)
+}
+
+// applyJitter случайно изменяет задержку delay в пределах доли jitter в обе стороны
+func applyJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration((rand.Float64()*2-1)*jitter*float64(delay))
+}
+
+// Pin закрепляет коммит по хешу commit или на back ревизий раньше текущего.
+// Операция выполняется в цикле синхронизации (Start).
+func (gitsync *GitSync) Pin(ctx context.Context, commit string, back int) (*git.PinInfo, error) {
+
+	var pin *git.PinInfo
+	err := gitsync.control(ctx, func(gitRepo interfaces.Gitter) error {
+		var err error
+		pin, err = gitRepo.Pin(gitsync.ctx, commit, back)
+		if err != nil {
+			return err
+		}
+
+		gitsync.updateStatus(gitRepo, nil)
+		if commit, err := gitRepo.Commit(); err == nil {
+			metrics.UpdateCommitInfo(commit, pin)
+		}
+		return nil
+	})
+
+	return pin, err
+}
+
+// Unpin снимает закрепление коммита и сразу выполняет синхронизацию с отслеживаемой ссылкой,
+// если синхронизация не приостановлена. Операция выполняется в цикле синхронизации (Start).
+func (gitsync *GitSync) Unpin(ctx context.Context) error {
+	return gitsync.control(ctx, func(gitRepo interfaces.Gitter) error {
+		if err := gitRepo.Unpin(); err != nil {
+			return err
+		}
+		return gitsync.followUp(gitRepo, "unpin")
+	})
+}
+
+// Approve подтверждает применение ревизии, задержанной директивой в сообщении коммита,
+// и сразу выполняет синхронизацию, если она не приостановлена.
+// Операция выполняется в цикле синхронизации (Start).
+func (gitsync *GitSync) Approve(ctx context.Context, commit string) (*git.HoldInfo, error) {
+
+	var held *git.HoldInfo
+	err := gitsync.control(ctx, func(gitRepo interfaces.Gitter) error {
+		var err error
+		held, err = gitRepo.Approve(commit)
+		if err != nil {
+			return err
+		}
+		return gitsync.followUp(gitRepo, "approve")
+	})
+
+	return held, err
+}
+
+// followUp выполняет синхронизацию после операции управления op. Приостановленная синхронизация
+// не выполняется: изменения будут применены после возобновления, обновляется только состояние.
+func (gitsync *GitSync) followUp(gitRepo interfaces.Gitter, op string) error {
+
+	if gitsync.Paused() != nil {
+		logger.GetLogger().Info("Sync: synchronization after %s skipped while paused\n", op)
+		gitsync.updateStatus(gitRepo, nil)
+		if commit, err := gitRepo.Commit(); err == nil {
+			metrics.UpdateCommitInfo(commit, gitRepo.Pinned())
+		}
+		return nil
+	}
+
+	return gitsync.Sync(gitRepo)
+}
+
+// control передает операцию op в цикл синхронизации и ожидает ее завершения
+func (gitsync *GitSync) control(ctx context.Context, op func(gitRepo interfaces.Gitter) error) error {
+
+	done := make(chan error, 1)
+	command := func(gitRepo interfaces.Gitter) {
+		done <- op(gitRepo)
+	}
+
+	select {
+	case gitsync.commands <- command:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-gitsync.ctx.Done():
+		return gitsync.ctx.Err()
+	}
+
+	// Операция уже выполняется и завершится независимо от отмены запроса
+	return <-done
+}
+
+// Status возвращает состояние последней синхронизации
+func (gitsync *GitSync) Status() models.SyncStatus {
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+	return gitsync.status
+}
+
 func (gitsync *GitSync) Stop() error {
 	return nil
 }
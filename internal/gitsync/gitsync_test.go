@@ -16,8 +16,13 @@ package gitsync_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"git-sync/git"
+	"git-sync/internal/constants"
 	"git-sync/internal/gitsync"
 	"git-sync/internal/handlers"
+	"git-sync/internal/models"
 	"git-sync/mock"
 	"testing"
 	"time"
@@ -76,3 +81,349 @@ func TestStart(t *testing.T) {
 		t.Error("Webhook channel was not emptied as expected")
 	}
 }
+
+func TestStatus(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	if err := gitSync.Sync(&mock.Gitter{}); err != nil {
+		t.Fatalf("Error syncing: %v", err)
+	}
+
+	status := gitSync.Status()
+	if status.Repository != "http://example.com" || status.Ref != "branch:master" {
+		t.Errorf("Unexpected repository in status: %+v", status)
+	}
+	if status.Commit == nil || status.Commit.Hash != "mockhash" {
+		t.Errorf("Expected commit mockhash in status, got %+v", status.Commit)
+	}
+	if status.LastSync.IsZero() || status.LastError != "" {
+		t.Errorf("Unexpected sync result in status: %+v", status)
+	}
+}
+
+// failingGitter возвращает ошибку синхронизации
+type failingGitter struct {
+	mock.Gitter
+	err error
+}
+
+func (m *failingGitter) Sync(ctx context.Context) error {
+	return m.err
+}
+
+func TestStatusState(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, models.StateOK},
+		{fmt.Errorf("network error"), models.StateError},
+		{fmt.Errorf("%w: rewrite", git.ErrDiverged), models.StateDiverged},
+	}
+
+	for _, tt := range tests {
+		gitSync.Sync(&failingGitter{err: tt.err})
+		if state := gitSync.Status().State; state != tt.expected {
+			t.Errorf("Expected state %s for error %v, got %s", tt.expected, tt.err, state)
+		}
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	failing := &failingGitter{err: fmt.Errorf("network error")}
+
+	for i := 1; i <= 3; i++ {
+		gitSync.Sync(failing)
+		if failures := gitSync.Status().ConsecutiveFailures; failures != i {
+			t.Errorf("Expected %d consecutive failures, got %d", i, failures)
+		}
+	}
+
+	// Успешная синхронизация сбрасывает счетчик
+	gitSync.Sync(&mock.Gitter{})
+	if failures := gitSync.Status().ConsecutiveFailures; failures != 0 {
+		t.Errorf("Expected no consecutive failures, got %d", failures)
+	}
+}
+
+func TestPin(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, ctx)
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	// Без цикла синхронизации операция ожидает его запуска до отмены запроса
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer timeoutCancel()
+	if _, err := gitSync.Pin(timeoutCtx, "abc", 0); err == nil {
+		t.Errorf("Expected error without synchronization loop")
+	}
+
+	go gitSync.Start(&mock.Gitter{})
+
+	pin, err := gitSync.Pin(context.Background(), "abc", 0)
+	if err != nil {
+		t.Fatalf("Error pinning: %v", err)
+	}
+	if status := gitSync.Status(); status.Pin == nil || status.Pin.Commit != pin.Commit {
+		t.Errorf("Expected pin in status, got %+v", status.Pin)
+	}
+
+	if err := gitSync.Unpin(context.Background()); err != nil {
+		t.Fatalf("Error unpinning: %v", err)
+	}
+	if status := gitSync.Status(); status.Pin != nil {
+		t.Errorf("Expected no pin in status, got %+v", status.Pin)
+	}
+}
+
+func TestPause(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	if gitSync.Resume("test") {
+		t.Errorf("Expected resume to fail when not paused")
+	}
+
+	gitSync.Pause("test", "maintenance", 0)
+	if status := gitSync.Status(); status.Paused == nil || status.Paused.By != "test" || status.Paused.Reason != "maintenance" {
+		t.Errorf("Expected pause in status, got %+v", status.Paused)
+	}
+
+	// Синхронизация по запросу сохраняет приостановку в состоянии
+	if err := gitSync.Sync(&mock.Gitter{}); err != nil {
+		t.Fatalf("Error syncing: %v", err)
+	}
+	if gitSync.Paused() == nil {
+		t.Errorf("Expected pause to be kept after sync")
+	}
+
+	if !gitSync.Resume("test") {
+		t.Errorf("Expected resume to succeed")
+	}
+	if status := gitSync.Status(); status.Paused != nil {
+		t.Errorf("Expected no pause in status, got %+v", status.Paused)
+	}
+
+	gitSync.TogglePause("signal", "SIGUSR1")
+	if gitSync.Paused() == nil {
+		t.Errorf("Expected toggle to pause")
+	}
+	gitSync.TogglePause("signal", "SIGUSR1")
+	if gitSync.Paused() != nil {
+		t.Errorf("Expected toggle to resume")
+	}
+
+	// Автоматическое возобновление по истечении времени
+	pause := gitSync.Pause("test", "deploy", 50*time.Millisecond)
+	if pause.Until == nil {
+		t.Fatalf("Expected resume deadline")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if gitSync.Paused() != nil {
+		t.Errorf("Expected automatic resume")
+	}
+}
+
+func TestHistory(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	mockFlags.String(constants.FlagDataDir, t.TempDir(), "Data directory")
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	gitSync.Sync(&mock.Gitter{})
+	gitSync.Sync(&failingGitter{err: fmt.Errorf("network error")})
+
+	page := gitSync.History(models.HistoryQuery{Limit: 10})
+	if page.Total != 2 {
+		t.Fatalf("Expected 2 history entries, got %+v", page)
+	}
+	if entry := page.Entries[0]; entry.Outcome != models.StateError || entry.Error == "" || entry.Trigger != models.TriggerManual {
+		t.Errorf("Expected failed manual sync, got %+v", entry)
+	}
+	if entry := page.Entries[1]; entry.Outcome != models.StateOK || entry.NewHash != "mockhash" || entry.End.Before(entry.Start) {
+		t.Errorf("Expected successful sync, got %+v", entry)
+	}
+
+	// История загружается из каталога данных после перезапуска
+	gitSync, err = gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+	if page := gitSync.History(models.HistoryQuery{Limit: 10}); page.Total != 2 {
+		t.Errorf("Expected 2 history entries after restart, got %d", page.Total)
+	}
+}
+
+func TestApprove(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, ctx)
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	go gitSync.Start(&mock.Gitter{})
+
+	// Без задержанной ревизии подтверждение отклоняется, синхронизация не выполняется
+	if _, err := gitSync.Approve(context.Background(), ""); !errors.Is(err, git.ErrInvalidApproval) {
+		t.Errorf("Expected invalid approval, got %v", err)
+	}
+	if status := gitSync.Status(); status.Held != nil {
+		t.Errorf("Expected no held revision, got %+v", status.Held)
+	}
+}
+
+// countingGitter считает выполненные синхронизации
+type countingGitter struct {
+	mock.Gitter
+	syncs int
+}
+
+func (m *countingGitter) Sync(ctx context.Context) error {
+	m.syncs++
+	return nil
+}
+
+func TestUnpinWhilePaused(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, ctx)
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	gitter := &countingGitter{}
+	go gitSync.Start(gitter)
+
+	if _, err := gitSync.Pin(context.Background(), "abc", 0); err != nil {
+		t.Fatalf("Error pinning: %v", err)
+	}
+
+	// Приостановленная синхронизация не выполняется после снятия закрепления
+	gitSync.Pause("test", "maintenance", 0)
+	syncs := gitter.syncs
+	if err := gitSync.Unpin(context.Background()); err != nil {
+		t.Fatalf("Error unpinning: %v", err)
+	}
+	if gitter.syncs != syncs {
+		t.Errorf("Expected no synchronization while paused, got %d", gitter.syncs-syncs)
+	}
+	if status := gitSync.Status(); status.Pin != nil || status.Paused == nil {
+		t.Errorf("Expected unpinned and paused status, got pin %+v, paused %+v", status.Pin, status.Paused)
+	}
+
+	// После возобновления снятие закрепления сразу синхронизирует репозиторий
+	gitSync.Resume("test")
+	if err := gitSync.Unpin(context.Background()); err != nil {
+		t.Fatalf("Error unpinning: %v", err)
+	}
+	if gitter.syncs != syncs+1 {
+		t.Errorf("Expected synchronization after unpin, got %d", gitter.syncs-syncs)
+	}
+}
+
+// changesGitter возвращает коммит с изменениями, часть которых отфильтрована фильтрами изменений
+type changesGitter struct {
+	mock.Gitter
+}
+
+func (m *changesGitter) HasChanges() bool {
+	return true
+}
+
+func (m *changesGitter) Commit() (*git.CommitInfo, error) {
+	return &git.CommitInfo{Hash: "mockhash", Date: time.Now(), Changes: []git.ChangeInfo{
+		{ChangeType: git.ChangeModify, FileName: "app/config.yaml"},
+		{ChangeType: git.ChangeModify, FileName: "README.md", Ignored: true},
+	}}, nil
+}
+
+func TestHistoryIgnoredChanges(t *testing.T) {
+
+	mockFlags := mock.Flags()
+	if err := mockFlags.Parse(nil); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	gitSync, err := gitsync.NewGitSync(mockFlags, context.Background())
+	if err != nil {
+		t.Fatalf("Error initializing GitSync: %v", err)
+	}
+
+	// Изменения, не прошедшие фильтры изменений, не учитываются
+	gitSync.Sync(&changesGitter{})
+	page := gitSync.History(models.HistoryQuery{Limit: 10})
+	if page.Total != 1 || page.Entries[0].Changes != 1 {
+		t.Errorf("Expected one relevant change in history, got %+v", page.Entries)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitsync
+
+import (
+	"git-sync/internal/metrics"
+	"git-sync/internal/models"
+	"git-sync/logger"
+	"time"
+)
+
+// Pause приостанавливает синхронизацию: цикл синхронизации пропускает срабатывания таймера и вебхуки.
+// by и reason сохраняются в состоянии синхронизации. Если duration больше нуля,
+// синхронизация возобновляется автоматически по истечении этого времени.
+// Повторный вызов заменяет параметры приостановки.
+func (gitsync *GitSync) Pause(by, reason string, duration time.Duration) models.PauseInfo {
+
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+
+	pause := &models.PauseInfo{
+		By:     by,
+		Reason: reason,
+		Since:  time.Now(),
+	}
+
+	if gitsync.resumeTimer != nil {
+		gitsync.resumeTimer.Stop()
+		gitsync.resumeTimer = nil
+	}
+
+	if duration > 0 {
+		until := pause.Since.Add(duration)
+		pause.Until = &until
+
+		// Возобновляем синхронизацию, если за это время приостановка не была заменена или снята
+		gitsync.resumeTimer = time.AfterFunc(duration, func() {
+			gitsync.mutex.Lock()
+			defer gitsync.mutex.Unlock()
+			if gitsync.status.Paused == pause {
+				gitsync.resume("deadline")
+			}
+		})
+	}
+
+	gitsync.status.Paused = pause
+	metrics.UpdatePauseInfo(pause)
+
+	logger.GetLogger().Warning("Sync: paused by %s: %s\n", by, reason)
+
+	return *pause
+}
+
+// Resume возобновляет приостановленную синхронизацию. Возвращает false, если синхронизация не была приостановлена.
+func (gitsync *GitSync) Resume(by string) bool {
+
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+
+	if gitsync.status.Paused == nil {
+		return false
+	}
+
+	gitsync.resume(by)
+
+	return true
+}
+
+// TogglePause приостанавливает синхронизацию или возобновляет ее, если она уже приостановлена
+func (gitsync *GitSync) TogglePause(by, reason string) {
+	if !gitsync.Resume(by) {
+		gitsync.Pause(by, reason, 0)
+	}
+}
+
+// Paused возвращает информацию о приостановке синхронизации или nil
+func (gitsync *GitSync) Paused() *models.PauseInfo {
+	gitsync.mutex.Lock()
+	defer gitsync.mutex.Unlock()
+	return gitsync.status.Paused
+}
+
+// resume снимает приостановку. Вызывается при заблокированном мьютексе.
+func (gitsync *GitSync) resume(by string) {
+
+	if gitsync.resumeTimer != nil {
+		gitsync.resumeTimer.Stop()
+		gitsync.resumeTimer = nil
+	}
+
+	gitsync.status.Paused = nil
+	metrics.UpdatePauseInfo(nil)
+
+	logger.GetLogger().Info("Sync: resumed by %s\n", by)
+}
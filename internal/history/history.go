@@ -0,0 +1,182 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Пакет history хранит ограниченную историю синхронизаций. Если задан файл,
+история сохраняется в нем после каждой записи и загружается при запуске.
+*/
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"git-sync/internal/models"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileName - имя файла истории в каталоге данных
+const FileName = "history.json"
+
+// History - ограниченная история синхронизаций
+type History struct {
+	mutex   sync.Mutex
+	path    string                // Файл истории (пусто - только в памяти)
+	size    int                   // Максимальное количество записей
+	lastID  int64                 // Номер последней записи
+	entries []models.HistoryEntry // Записи от старых к новым
+}
+
+// New создает историю из size записей. Если path не пуст, записи загружаются из файла path,
+// а каталог файла создается при необходимости.
+func New(path string, size int) (*History, error) {
+
+	history := &History{
+		path: path,
+		size: size,
+	}
+
+	if path == "" {
+		return history, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &history.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %v", path, err)
+	}
+
+	history.trim()
+	if n := len(history.entries); n > 0 {
+		history.lastID = history.entries[n-1].ID
+	}
+
+	return history, nil
+}
+
+// Add добавляет запись entry, присваивая ей порядковый номер, и сохраняет историю в файл.
+// Запись остается в памяти, даже если сохранить файл не удалось.
+func (history *History) Add(entry models.HistoryEntry) (models.HistoryEntry, error) {
+
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	if history.size <= 0 {
+		return entry, nil
+	}
+
+	history.lastID++
+	entry.ID = history.lastID
+	history.entries = append(history.entries, entry)
+	history.trim()
+
+	return entry, history.save()
+}
+
+// Query возвращает страницу записей, подходящих под фильтры query, от новых к старым
+func (history *History) Query(query models.HistoryQuery) models.HistoryPage {
+
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	page := models.HistoryPage{
+		Offset:  query.Offset,
+		Limit:   query.Limit,
+		Entries: []models.HistoryEntry{},
+	}
+
+	for i := len(history.entries) - 1; i >= 0; i-- {
+		entry := history.entries[i]
+		if !matches(entry, query) {
+			continue
+		}
+		if page.Total >= query.Offset && len(page.Entries) < query.Limit {
+			page.Entries = append(page.Entries, entry)
+		}
+		page.Total++
+	}
+
+	return page
+}
+
+// matches проверяет, подходит ли запись entry под фильтры query
+func matches(entry models.HistoryEntry, query models.HistoryQuery) bool {
+	switch {
+	case query.Trigger != "" && entry.Trigger != query.Trigger:
+		return false
+	case query.Outcome != "" && entry.Outcome != query.Outcome:
+		return false
+	case !query.Since.IsZero() && entry.Start.Before(query.Since):
+		return false
+	case !query.Until.IsZero() && !entry.Start.Before(query.Until):
+		return false
+	default:
+		return true
+	}
+}
+
+// trim удаляет самые старые записи сверх size. Вызывается при заблокированном мьютексе.
+func (history *History) trim() {
+	if extra := len(history.entries) - history.size; extra > 0 {
+		history.entries = append([]models.HistoryEntry(nil), history.entries[extra:]...)
+	}
+}
+
+// save атомарно записывает историю в файл: сначала во временный файл, который затем переименовывается.
+// Вызывается при заблокированном мьютексе.
+func (history *History) save() error {
+
+	if history.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(history.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(history.path), filepath.Base(history.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), history.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"git-sync/internal/history"
+	"git-sync/internal/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryPersistence(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "data", history.FileName)
+
+	h, err := history.New(path, 3)
+	if err != nil {
+		t.Fatalf("Error creating history: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := h.Add(models.HistoryEntry{Trigger: models.TriggerTick, Outcome: models.StateOK}); err != nil {
+			t.Fatalf("Error adding entry: %v", err)
+		}
+	}
+
+	// После перезапуска загружаются последние записи, нумерация продолжается
+	h, err = history.New(path, 3)
+	if err != nil {
+		t.Fatalf("Error loading history: %v", err)
+	}
+
+	page := h.Query(models.HistoryQuery{Limit: 10})
+	if page.Total != 3 || len(page.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", page)
+	}
+	if page.Entries[0].ID != 5 || page.Entries[2].ID != 3 {
+		t.Errorf("Expected entries 5..3 from newest to oldest, got %d..%d", page.Entries[0].ID, page.Entries[2].ID)
+	}
+
+	entry, err := h.Add(models.HistoryEntry{Trigger: models.TriggerManual})
+	if err != nil {
+		t.Fatalf("Error adding entry: %v", err)
+	}
+	if entry.ID != 6 {
+		t.Errorf("Expected entry 6, got %d", entry.ID)
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+
+	h, err := history.New("", 100)
+	if err != nil {
+		t.Fatalf("Error creating history: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		entry := models.HistoryEntry{
+			Start:   start.Add(time.Duration(i) * time.Minute),
+			Trigger: models.TriggerTick,
+			Outcome: models.StateOK,
+		}
+		if i%2 == 1 {
+			entry.Trigger = models.TriggerWebhook
+			entry.Outcome = models.StateError
+		}
+		h.Add(entry)
+	}
+
+	tests := []struct {
+		name  string
+		query models.HistoryQuery
+		total int
+		ids   []int64
+	}{
+		{"page", models.HistoryQuery{Offset: 2, Limit: 3}, 10, []int64{8, 7, 6}},
+		{"trigger", models.HistoryQuery{Trigger: models.TriggerWebhook, Limit: 2}, 5, []int64{10, 8}},
+		{"outcome", models.HistoryQuery{Outcome: models.StateOK, Offset: 4, Limit: 10}, 5, []int64{1}},
+		{"time", models.HistoryQuery{Since: start.Add(2 * time.Minute), Until: start.Add(5 * time.Minute), Limit: 10}, 3, []int64{5, 4, 3}},
+		{"empty", models.HistoryQuery{Trigger: models.TriggerManual, Limit: 10}, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := h.Query(tt.query)
+			if page.Total != tt.total {
+				t.Errorf("Expected total %d, got %d", tt.total, page.Total)
+			}
+			if len(page.Entries) != len(tt.ids) {
+				t.Fatalf("Expected %d entries, got %+v", len(tt.ids), page.Entries)
+			}
+			for i, id := range tt.ids {
+				if page.Entries[i].ID != id {
+					t.Errorf("Expected entry %d at %d, got %d", id, i, page.Entries[i].ID)
+				}
+			}
+		})
+	}
+}
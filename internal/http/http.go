@@ -18,8 +18,10 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"git-sync/api"
 	"git-sync/internal/constants"
 	"git-sync/internal/handlers"
+	"git-sync/internal/interfaces"
 	"git-sync/logger"
 	"net/http"
 	"sort"
@@ -98,7 +100,7 @@ func rootHandlerFunc(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "</ul>\n")
 }
 
-func StartServer(f *flag.FlagSet, ctx context.Context) {
+func StartServer(f *flag.FlagSet, ctx context.Context, status interfaces.StatusProvider, controller interfaces.Controller, history interfaces.HistoryProvider) {
 
 	addr := f.Lookup(constants.FlagHttpServerAddr).Value.(flag.Getter).Get().(string)
 	basicUsername := f.Lookup(constants.FlagHttpServerAuthUsername).Value.(flag.Getter).Get().(string)
@@ -134,6 +136,18 @@ func StartServer(f *flag.FlagSet, ctx context.Context) {
 
 	registerHandler("/metrics", chain.Then(handlers.MetricsHandler()), nil)
 	registerHandler("/webhook", chain.Then(http.HandlerFunc(handlers.WebhookHandlerFunc)), nil)
+	registerHandler("/status", chain.Then(api.StatusHandler(status)), nil)
+	registerHandler("/history", chain.Then(api.HistoryHandler(history)), nil)
+
+	// Управление синхронизацией доступно только с аутентификацией
+	if useBasicAuth || useBaererToken {
+		registerHandler("/pin", chain.Then(api.PinHandler(controller, status)), nil)
+		registerHandler("/pause", chain.Then(api.PauseHandler(controller, status)), nil)
+		registerHandler("/resume", chain.Then(api.ResumeHandler(controller, status)), nil)
+		registerHandler("/approve", chain.Then(api.ApproveHandler(controller, status)), nil)
+	} else {
+		logger.GetLogger().Warning("HTTP server: control endpoints are disabled without authentication\n")
+	}
 	registerHandler("/", nil, rootHandlerFunc)
 
 	go func() {
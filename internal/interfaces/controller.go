@@ -0,0 +1,43 @@
+// Copyright 2024 Aleksey Dobshikov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interfaces
+
+import (
+	"context"
+	"git-sync/git"
+	"git-sync/internal/models"
+	"time"
+)
+
+type Controller interface {
+
+	// Pin закрепляет коммит по хешу или на back ревизий раньше текущего
+	// и прекращает следование за отслеживаемой ссылкой.
+	Pin(ctx context.Context, commit string, back int) (*git.PinInfo, error)
+
+	// Unpin снимает закрепление и возвращает синхронизацию к отслеживаемой ссылке.
+	Unpin(ctx context.Context) error
+
+	// Approve подтверждает применение ревизии, задержанной директивой в сообщении коммита,
+	// и сразу выполняет синхронизацию.
+	Approve(ctx context.Context, commit string) (*git.HoldInfo, error)
+
+	// Pause приостанавливает синхронизацию. Если duration больше нуля,
+	// синхронизация возобновляется автоматически по истечении этого времени.
+	Pause(by, reason string, duration time.Duration) models.PauseInfo
+
+	// Resume возобновляет синхронизацию. Возвращает false, если она не была приостановлена.
+	Resume(by string) bool
+}
@@ -14,12 +14,15 @@
 
 package interfaces
 
-import "git-sync/git"
+import (
+	"context"
+	"git-sync/git"
+)
 
 type Gitter interface {
 
 	// Sync выполняет синхронизацию локального и удаленного репозитория
-	Sync() error
+	Sync(ctx context.Context) error
 
 	// Options возвращает настройки GitRepository
 	Options() *git.GitRepositoryOptions
@@ -32,4 +35,28 @@ type Gitter interface {
 
 	// CommitHash получает текущий хеш коммита
 	CommitHash() string
+
+	// LastUpdate получает обновление отслеживаемой ссылки, найденное при последней синхронизации
+	LastUpdate() *git.UpdateInfo
+
+	// LastPush получает информацию об отправке локальных изменений при последней синхронизации
+	LastPush() *git.PushInfo
+
+	// DryRun получает отчет последней синхронизации в режиме dry-run или nil, если режим не включен
+	DryRun() *git.DryRunInfo
+
+	// Pin закрепляет коммит по хешу или на back ревизий раньше текущего
+	Pin(ctx context.Context, commit string, back int) (*git.PinInfo, error)
+
+	// Unpin снимает закрепление коммита
+	Unpin() error
+
+	// Pinned получает закрепленный коммит или nil, если закрепления нет
+	Pinned() *git.PinInfo
+
+	// Held получает ревизию, задержанную директивой в сообщении коммита, или nil
+	Held() *git.HoldInfo
+
+	// Approve подтверждает применение задержанной ревизии
+	Approve(commit string) (*git.HoldInfo, error)
 }
@@ -17,6 +17,7 @@ package metrics
 import (
 	"fmt"
 	"git-sync/git"
+	"git-sync/internal/models"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -43,18 +44,99 @@ var (
 		},
 	)
 
+	SyncDivergedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "git_sync_sync_diverged_count",
+			Help: "Total number of updates refused because the remote history diverged (fast-forward-only mode)",
+		},
+	)
+
+	SyncConsecutiveFailures = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "git_sync_sync_consecutive_failures",
+			Help: "Number of consecutive failed synchronizations",
+		},
+	)
+
+	SyncNextTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "git_sync_sync_next_timestamp_seconds",
+			Help: "Unix time of the next synchronization attempt",
+		},
+	)
+
 	SyncRepoInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "git_sync_repo_info",
 			Help: "Information about the synchronized repository",
 		},
-		[]string{"repository", "branch"},
+		[]string{"repository", "branch", "ref"},
 	)
 
 	CommitInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "git_sync_commit_info",
 		Help: "Information about the latest commit.",
-	}, []string{"hash", "author", "email", "date", "message"})
+	}, []string{"hash", "author", "email", "date", "message", "pinned"})
+
+	SubmoduleInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_submodule_info",
+		Help: "Information about the submodules of the latest commit.",
+	}, []string{"path", "hash"})
+
+	CommitChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_commit_changes",
+		Help: "Number of changed files in the latest synchronization by change type.",
+	}, []string{"type"})
+
+	UpdateCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "git_sync_update_count",
+		Help: "Total number of updates of the tracked reference by kind and applied policy.",
+	}, []string{"kind", "action"})
+
+	PushCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "git_sync_push_count",
+		Help: "Total number of attempts to push local changes by result.",
+	}, []string{"result"})
+
+	PushConflictFiles = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "git_sync_push_conflict_files",
+		Help: "Number of files in conflict with the remote branch in the latest push attempt.",
+	})
+
+	SyncPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "git_sync_sync_paused",
+		Help: "Whether synchronization is paused (1) or running (0).",
+	})
+
+	PauseInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_pause_info",
+		Help: "Information about the current pause: who paused synchronization and why.",
+	}, []string{"by", "reason"})
+
+	PauseUntilTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "git_sync_pause_until_timestamp_seconds",
+		Help: "Unix time of the automatic resume of paused synchronization (0 - no deadline).",
+	})
+
+	DryRunPendingChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_dry_run_pending_changes",
+		Help: "Number of files that synchronization would change in dry-run mode by source (remote, local, clean).",
+	}, []string{"source"})
+
+	DryRunPendingUpdate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_dry_run_pending_update",
+		Help: "Pending update of the tracked reference in dry-run mode by update kind and policy action.",
+	}, []string{"kind", "action"})
+
+	HeldRevision = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "git_sync_held_revision_info",
+		Help: "Revision of the tracked reference held by a commit message directive ([skip sync], [hold]).",
+	}, []string{"commit", "directive", "reason"})
+
+	ChangesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "git_sync_changes_total",
+		Help: "Total number of changed files by change type.",
+	}, []string{"type"})
 )
 
 func init() {
@@ -62,16 +144,106 @@ func init() {
 	prometheus.MustRegister(SyncRepoInfo)
 	prometheus.MustRegister(SyncTotalCount)
 	prometheus.MustRegister(SyncTotalErrorCount)
+	prometheus.MustRegister(SyncDivergedCount)
+	prometheus.MustRegister(SyncConsecutiveFailures)
+	prometheus.MustRegister(SyncNextTimestamp)
 	prometheus.MustRegister(CommitInfo)
+	prometheus.MustRegister(SubmoduleInfo)
+	prometheus.MustRegister(CommitChanges)
+	prometheus.MustRegister(ChangesTotal)
+	prometheus.MustRegister(UpdateCount)
+	prometheus.MustRegister(PushCount)
+	prometheus.MustRegister(PushConflictFiles)
+	prometheus.MustRegister(SyncPaused)
+	prometheus.MustRegister(PauseInfo)
+	prometheus.MustRegister(PauseUntilTimestamp)
+	prometheus.MustRegister(DryRunPendingChanges)
+	prometheus.MustRegister(DryRunPendingUpdate)
+	prometheus.MustRegister(HeldRevision)
 }
 
-func UpdateCommitInfo(gci *git.CommitInfo) {
+func UpdateCommitInfo(gci *git.CommitInfo, pin *git.PinInfo) {
 	unixTimestamp := gci.Date.UnixNano() / int64(time.Millisecond)
 	CommitInfo.Reset()
-	CommitInfo.WithLabelValues(gci.Hash, gci.Author, gci.Email, fmt.Sprintf("%d", unixTimestamp), gci.Message).Set(1)
+	CommitInfo.WithLabelValues(gci.Hash, gci.Author, gci.Email, fmt.Sprintf("%d", unixTimestamp), gci.Message, fmt.Sprint(pin != nil)).Set(1)
+
+	SubmoduleInfo.Reset()
+	for _, submodule := range gci.Submodules {
+		SubmoduleInfo.WithLabelValues(submodule.Path, submodule.Hash).Set(1)
+	}
+
+	CommitChanges.Reset()
+	for changeType, count := range countChanges(gci) {
+		CommitChanges.WithLabelValues(changeType).Set(float64(count))
+	}
+}
+
+// AddUpdate увеличивает счетчик обновлений отслеживаемой ссылки
+func AddUpdate(update *git.UpdateInfo) {
+	UpdateCount.WithLabelValues(update.Kind, update.Action).Inc()
+}
+
+// AddPush увеличивает счетчик отправок локальных изменений и обновляет количество конфликтующих файлов
+func AddPush(push *git.PushInfo) {
+	PushCount.WithLabelValues(push.Result).Inc()
+	PushConflictFiles.Set(float64(len(push.Conflicts)))
+}
+
+// UpdatePauseInfo обновляет метрики приостановки синхронизации (nil - синхронизация не приостановлена)
+func UpdatePauseInfo(pause *models.PauseInfo) {
+	PauseInfo.Reset()
+	PauseUntilTimestamp.Set(0)
+
+	if pause == nil {
+		SyncPaused.Set(0)
+		return
+	}
+
+	SyncPaused.Set(1)
+	PauseInfo.WithLabelValues(pause.By, pause.Reason).Set(1)
+	if pause.Until != nil {
+		PauseUntilTimestamp.Set(float64(pause.Until.Unix()))
+	}
+}
+
+// UpdateDryRun обновляет метрики действий, которые выполнила бы синхронизация в режиме dry-run
+func UpdateDryRun(plan *git.DryRunInfo) {
+	DryRunPendingChanges.WithLabelValues("remote").Set(float64(len(plan.Changes)))
+	DryRunPendingChanges.WithLabelValues("local").Set(float64(len(plan.LocalChanges)))
+	DryRunPendingChanges.WithLabelValues("clean").Set(float64(len(plan.Clean)))
+
+	DryRunPendingUpdate.Reset()
+	if plan.Update != "" {
+		DryRunPendingUpdate.WithLabelValues(plan.Update, plan.Action).Set(1)
+	}
+}
+
+// UpdateHeldRevision обновляет метрику задержанной ревизии (nil - ревизия не задержана)
+func UpdateHeldRevision(held *git.HoldInfo) {
+	HeldRevision.Reset()
+	if held != nil {
+		HeldRevision.WithLabelValues(held.Commit, held.Directive, held.Reason).Set(1)
+	}
+}
+
+// AddChanges увеличивает счетчики изменений файлов по типам изменений
+func AddChanges(gci *git.CommitInfo) {
+	// Изменения, не прошедшие фильтры изменений, не учитываются
+	for _, change := range gci.RelevantChanges() {
+		ChangesTotal.WithLabelValues(change.ChangeType).Inc()
+	}
+}
+
+// countChanges возвращает количество изменений файлов коммита по типам изменений
+func countChanges(gci *git.CommitInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, change := range gci.Changes {
+		counts[change.ChangeType]++
+	}
+	return counts
 }
 
 func UpdateSyncRepoInfo(gro *git.GitRepositoryOptions) {
 	SyncRepoInfo.Reset()
-	SyncRepoInfo.WithLabelValues(gro.Url(), gro.Branch()).Set(1)
+	SyncRepoInfo.WithLabelValues(gro.Url(), gro.Branch(), gro.Ref()).Set(1)
 }
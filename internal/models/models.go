@@ -13,3 +13,83 @@
 // limitations under the License.
 
 package models
+
+import (
+	"git-sync/git"
+	"time"
+)
+
+// Состояния синхронизации
+const (
+	StateOK       string = "ok"       // последняя синхронизация выполнена успешно
+	StateError    string = "error"    // последняя синхронизация завершилась ошибкой
+	StateDiverged string = "diverged" // история удаленного репозитория разошлась с текущим коммитом (fast-forward-only)
+	StateConflict string = "conflict" // локальные изменения конфликтуют с удаленной веткой (режим отправки)
+)
+
+// Источники запуска синхронизации
+const (
+	TriggerTick    string = "tick"    // срабатывание таймера
+	TriggerWebhook string = "webhook" // вебхук
+	TriggerManual  string = "manual"  // операция управления (снятие закрепления) или прямой вызов
+)
+
+// HistoryEntry содержит запись истории синхронизаций
+type HistoryEntry struct {
+	ID      int64     `json:"id"`                 // Порядковый номер записи
+	Start   time.Time `json:"start"`              // Время начала синхронизации
+	End     time.Time `json:"end"`                // Время завершения синхронизации
+	Trigger string    `json:"trigger"`            // Источник запуска (tick, webhook, manual)
+	Outcome string    `json:"outcome"`            // Результат (ok, error, diverged, conflict)
+	Error   string    `json:"error,omitempty"`    // Ошибка синхронизации
+	OldHash string    `json:"old_hash,omitempty"` // Коммит до синхронизации
+	NewHash string    `json:"new_hash,omitempty"` // Коммит после синхронизации
+	Changes int       `json:"changes"`            // Количество измененных файлов
+}
+
+// HistoryQuery содержит параметры выборки из истории синхронизаций.
+// Пустые значения фильтров не ограничивают выборку.
+type HistoryQuery struct {
+	Trigger string    // Источник запуска
+	Outcome string    // Результат
+	Since   time.Time // Синхронизации, начатые не раньше этого времени
+	Until   time.Time // Синхронизации, начатые раньше этого времени
+	Offset  int       // Количество пропускаемых записей
+	Limit   int       // Максимальное количество записей
+}
+
+// HistoryPage содержит страницу истории синхронизаций, от новых записей к старым
+type HistoryPage struct {
+	Total   int            `json:"total"`   // Количество записей, подходящих под фильтры
+	Offset  int            `json:"offset"`  // Количество пропущенных записей
+	Limit   int            `json:"limit"`   // Максимальное количество записей на странице
+	Entries []HistoryEntry `json:"entries"` // Записи истории
+}
+
+// PauseInfo содержит информацию о приостановке синхронизации
+type PauseInfo struct {
+	By     string     `json:"by"`              // Кто приостановил синхронизацию
+	Reason string     `json:"reason"`          // Причина приостановки
+	Since  time.Time  `json:"since"`           // Время приостановки
+	Until  *time.Time `json:"until,omitempty"` // Время автоматического возобновления
+}
+
+// SyncStatus содержит состояние синхронизации репозитория
+type SyncStatus struct {
+	State      string          `json:"state"`                 // Состояние синхронизации (ok, error, diverged, conflict)
+	Repository string          `json:"repository"`            // URL удаленного репозитория
+	Ref        string          `json:"ref"`                   // Отслеживаемая ссылка
+	Commit     *git.CommitInfo `json:"commit,omitempty"`      // Текущий коммит с изменениями последней синхронизации
+	HasChanges bool            `json:"has_changes"`           // Последняя синхронизация нашла изменения
+	LastUpdate *git.UpdateInfo `json:"last_update,omitempty"` // Последнее обновление отслеживаемой ссылки
+	LastPush   *git.PushInfo   `json:"last_push,omitempty"`   // Последняя отправка локальных изменений
+	Pin        *git.PinInfo    `json:"pin,omitempty"`         // Закрепленный коммит
+	Paused     *PauseInfo      `json:"paused,omitempty"`      // Приостановка синхронизации
+	DryRun     *git.DryRunInfo `json:"dry_run,omitempty"`     // Действия, которые выполнила бы синхронизация (режим dry-run)
+	Held       *git.HoldInfo   `json:"held,omitempty"`        // Ревизия, задержанная директивой в сообщении коммита
+	LastSync   time.Time       `json:"last_sync"`             // Время последней синхронизации
+	LastError  string          `json:"last_error,omitempty"`  // Ошибка последней синхронизации
+
+	ConsecutiveFailures int       `json:"consecutive_failures"` // Количество ошибок синхронизации подряд
+	NextSync            time.Time `json:"next_sync"`            // Время следующей попытки синхронизации
+}
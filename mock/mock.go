@@ -15,6 +15,7 @@
 package mock
 
 import (
+	"context"
 	"flag"
 	"git-sync/git"
 	"git-sync/internal/constants"
@@ -32,9 +33,10 @@ func Flags() *flag.FlagSet {
 
 type Gitter struct {
 	hasChanges bool
+	pin        *git.PinInfo
 }
 
-func (m *Gitter) Sync() error {
+func (m *Gitter) Sync(ctx context.Context) error {
 	return nil
 }
 
@@ -53,3 +55,37 @@ func (m *Gitter) Commit() (*git.CommitInfo, error) {
 func (m *Gitter) CommitHash() string {
 	return "mockhash"
 }
+
+func (m *Gitter) LastUpdate() *git.UpdateInfo {
+	return nil
+}
+
+func (m *Gitter) LastPush() *git.PushInfo {
+	return nil
+}
+
+func (m *Gitter) DryRun() *git.DryRunInfo {
+	return nil
+}
+
+func (m *Gitter) Pin(ctx context.Context, commit string, back int) (*git.PinInfo, error) {
+	m.pin = &git.PinInfo{Commit: commit, Time: time.Now()}
+	return m.pin, nil
+}
+
+func (m *Gitter) Unpin() error {
+	m.pin = nil
+	return nil
+}
+
+func (m *Gitter) Pinned() *git.PinInfo {
+	return m.pin
+}
+
+func (m *Gitter) Held() *git.HoldInfo {
+	return nil
+}
+
+func (m *Gitter) Approve(commit string) (*git.HoldInfo, error) {
+	return nil, git.ErrInvalidApproval
+}